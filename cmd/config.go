@@ -0,0 +1,33 @@
+//
+// Copyright (c) 2024 Quadient Group AG
+//
+// This file is subject to the terms and conditions defined in the
+// 'LICENSE' file found in the root of this source code package.
+//
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// configCmd represents the config command.
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Operations with the configuration",
+	Long: `
+Performs operations against the application configuration
+	`,
+	Example: `
+# check a configuration file for problems
+spt-util config validate -c <path-to-config.yaml>
+
+# display the effective configuration and where each value came from
+spt-util config view --show-origin
+	`,
+}
+
+//nolint:gochecknoinits // required for proper cobra initialization.
+func init() {
+	rootCmd.AddCommand(configCmd)
+}
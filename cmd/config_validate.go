@@ -0,0 +1,86 @@
+//
+// Copyright (c) 2024 Quadient Group AG
+//
+// This file is subject to the terms and conditions defined in the
+// 'LICENSE' file found in the root of this source code package.
+//
+
+package cmd
+
+import (
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/robertwtucker/spt-util/pkg/config"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var validateCmdArgs struct {
+	PrintSchema bool
+}
+
+// validateCmd represents the config validate command.
+var validateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Validates the configuration",
+	Long: `
+Validates the effective configuration (config file, environment variables and
+flags) against the configuration schema and reports every problem found with
+its key path, e.g. demo.stage.files[0].dest. The same validation runs
+automatically before every demo command.
+	`,
+	Example: `
+# check a configuration file for problems
+spt-util config validate -c <path-to-config.yaml>
+
+# print the configuration schema, e.g. for use in an editor
+spt-util config validate --print-schema > spt-util.schema.json
+	`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if validateCmdArgs.PrintSchema {
+			_, _ = os.Stdout.Write(config.Schema)
+			return
+		}
+		if err := validateConfig(); err != nil {
+			log.Fatal(err)
+		}
+		log.WithField("config", viper.ConfigFileUsed()).Info("configuration is valid")
+	},
+}
+
+//nolint:gochecknoinits // required for proper cobra initialization.
+func init() {
+	validateCmd.Flags().BoolVar(&validateCmdArgs.PrintSchema, "print-schema",
+		false, "print the configuration schema instead of validating")
+
+	configCmd.AddCommand(validateCmd)
+}
+
+// validateConfig validates the effective configuration, logging each
+// problem found.
+func validateConfig() error {
+	problems, err := config.Validate(viper.AllSettings())
+	if err != nil {
+		return err
+	}
+	for _, problem := range problems {
+		log.WithField("key", problem.Path).Error("invalid configuration: ", problem.Message)
+	}
+	if len(problems) > 0 {
+		return errors.Errorf("configuration has %d problem(s)", len(problems))
+	}
+	return nil
+}
+
+// isDemoCommand reports whether cmd is the demo command or one of its
+// subcommands.
+func isDemoCommand(cmd *cobra.Command) bool {
+	for c := cmd; c != nil; c = c.Parent() {
+		if c == demoCmd {
+			return true
+		}
+	}
+	return false
+}
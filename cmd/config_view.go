@@ -0,0 +1,95 @@
+//
+// Copyright (c) 2024 Quadient Group AG
+//
+// This file is subject to the terms and conditions defined in the
+// 'LICENSE' file found in the root of this source code package.
+//
+
+package cmd
+
+import (
+	"os"
+	"strings"
+
+	"github.com/robertwtucker/spt-util/pkg/config"
+	"github.com/robertwtucker/spt-util/pkg/constants"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var viewCmdArgs struct {
+	Output     string
+	ShowOrigin bool
+}
+
+// viewCmd represents the config view command.
+var viewCmd = &cobra.Command{
+	Use:   "view",
+	Short: "Displays the effective configuration",
+	Long: `
+Displays the effective configuration after merging the config file, environment
+variables and flags. Secret values such as passwords and tokens are redacted.
+With --show-origin, each value is annotated with where it came from: default,
+file, profile, env, flag, secret-dir (read from demo.secretDir) or kubernetes
+(discovered from the Helm release).
+	`,
+	Example: `
+# display the effective configuration
+spt-util config view
+
+# display where each value came from, as JSON
+spt-util config view --show-origin -o json
+	`,
+	Run: func(cmd *cobra.Command, args []string) {
+		settings, err := config.Canonicalize(viper.AllSettings())
+		if err != nil {
+			log.Fatalf("error reading configuration: %s", err)
+		}
+
+		var origins map[string]string
+		if viewCmdArgs.ShowOrigin {
+			origins = map[string]string{}
+			for _, key := range config.Keys(settings) {
+				origins[key] = configOrigin(key)
+			}
+		}
+		if err = config.Write(os.Stdout, config.Redact(settings), viewCmdArgs.Output, origins); err != nil {
+			log.Fatalf("error displaying configuration: %s", err)
+		}
+	},
+}
+
+//nolint:gochecknoinits // required for proper cobra initialization.
+func init() {
+	viewCmd.Flags().StringVarP(&viewCmdArgs.Output, "output", "o",
+		config.FormatYAML, "set the output format [yaml|json]")
+	viewCmd.Flags().BoolVar(&viewCmdArgs.ShowOrigin, "show-origin",
+		false, "annotate each value with its origin")
+
+	configCmd.AddCommand(viewCmd)
+}
+
+// configOrigin returns where the effective value of a configuration
+// key came from, following viper's precedence.
+func configOrigin(key string) string {
+	if flag, ok := flagBindings[strings.ToLower(key)]; ok && flag.Changed {
+		return config.OriginFlag
+	}
+	if origin, ok := derivedOrigins[strings.ToLower(key)]; ok {
+		return origin
+	}
+	if env, ok := envBindings[strings.ToLower(key)]; ok && os.Getenv(env) != "" {
+		return config.OriginEnv
+	}
+	if os.Getenv(strings.ToUpper(key)) != "" {
+		return config.OriginEnv
+	}
+	if activeProfile != "" && viper.InConfig(constants.ProfilesKey+"."+activeProfile+"."+key) {
+		return config.OriginProfile
+	}
+	if viper.InConfig(key) {
+		return config.OriginFile
+	}
+	return config.OriginDefault
+}
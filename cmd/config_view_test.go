@@ -0,0 +1,75 @@
+//
+// Copyright (c) 2024 Quadient Group AG
+//
+// This file is subject to the terms and conditions defined in the
+// 'LICENSE' file found in the root of this source code package.
+//
+
+package cmd_test
+
+import (
+	"testing"
+
+	"github.com/robertwtucker/spt-util/cmd"
+	"github.com/robertwtucker/spt-util/pkg/config"
+	"github.com/robertwtucker/spt-util/pkg/constants"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigOrigin(t *testing.T) {
+	readConfig(t, profileConfig)
+	cmd.SetProfile(t, "acme-prod")
+	require.NoError(t, cmd.ApplyProfile())
+	cmd.SetConfigFlag(t, constants.DemoStageWorkersKey, "8")
+	t.Setenv(constants.DemoServerEnv, "https://scaler.env.example.com")
+	t.Setenv(constants.ServeTokenEnv, "secret")
+	t.Setenv(constants.TracingEnv, "none")
+	t.Setenv("DEMO.USERNAME", "user")
+
+	for key, origin := range map[string]string{
+		constants.DemoStageWorkersKey: config.OriginFlag,
+		constants.DemoServerKey:       config.OriginEnv, // bound environment variables
+		constants.ServeTokenKey:       config.OriginEnv,
+		constants.TracingExporterKey:  config.OriginEnv,
+		constants.DemoUsernameKey:     config.OriginEnv, // automatic environment variable
+		constants.GlobalNamespaceKey:  config.OriginProfile,
+		constants.GlobalReleaseKey:    config.OriginFile,
+		constants.DemoWaitTimeoutKey:  config.OriginDefault,
+	} {
+		assert.Equal(t, origin, cmd.ConfigOrigin(key), key)
+	}
+}
+
+func TestConfigOrigin_FlagOverridesFile(t *testing.T) {
+	readConfig(t, profileConfig)
+	assert.Equal(t, config.OriginFile, cmd.ConfigOrigin(constants.GlobalNamespaceKey))
+
+	cmd.SetConfigFlag(t, constants.GlobalNamespaceKey, "flag-ns")
+	assert.Equal(t, config.OriginFlag, cmd.ConfigOrigin(constants.GlobalNamespaceKey))
+}
+
+func TestConfigOrigin_BoundFlags(t *testing.T) {
+	keys := cmd.FlagKeys()
+	for _, key := range []string{
+		constants.GlobalReleaseKey,
+		constants.GlobalNamespaceKey,
+		constants.DemoStageWorkersKey,
+		constants.DemoStageManifestKey,
+		constants.DemoWaitTimeoutKey,
+		constants.DemoWaitIntervalKey,
+		constants.ServeAddressKey,
+		constants.EventsFileKey,
+	} {
+		assert.Contains(t, keys, key)
+	}
+
+	// Every value given as a flag is reported as such.
+	for _, key := range keys {
+		t.Run(key, func(t *testing.T) {
+			assert.Equal(t, config.OriginDefault, cmd.ConfigOrigin(key))
+			cmd.SetConfigFlag(t, key, cmd.FlagDefault(key))
+			assert.Equal(t, config.OriginFlag, cmd.ConfigOrigin(key))
+		})
+	}
+}
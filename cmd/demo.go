@@ -8,11 +8,21 @@
 package cmd
 
 import (
+	"strings"
+
 	"github.com/robertwtucker/spt-util/pkg/constants"
+	"github.com/robertwtucker/spt-util/pkg/metrics"
+	"github.com/robertwtucker/spt-util/pkg/report"
+	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
+var demoCmdArgs struct {
+	ReportFile   string
+	ReportFormat string
+}
+
 // demoCmd represents the demo command.
 var demoCmd = &cobra.Command{
 	Use:   "demo",
@@ -26,15 +36,67 @@ spt-util demo init -d
 
 # stage files in a demo environment using a custom configuration file
 spt-util demo stage -c <path-to-config.yaml>
+
+# initialize a demo environment and write a JUnit run report for CI
+spt-util demo init --report out/init.xml --report-format junit
 	`,
 }
 
 //nolint:gochecknoinits // required for proper cobra initialization.
 func init() {
-	// Get Scaler params from environment, not command line
-	_ = viper.BindEnv(constants.DemoUsernameKey, constants.DemoUsernameEnv)
-	_ = viper.BindEnv(constants.DemoPasswordKey, constants.DemoPasswordEnv)
-	_ = viper.BindEnv(constants.DemoServerKey, constants.DemoServerEnv)
+	// Get Scaler params and credentials from environment, not command line
+	bindEnv(constants.DemoUsernameKey, constants.DemoUsernameEnv)
+	bindEnv(constants.DemoPasswordKey, constants.DemoPasswordEnv)
+	bindEnv(constants.DemoServerKey, constants.DemoServerEnv)
+	bindEnv(constants.DemoSecretDirKey, constants.DemoSecretDirEnv)
+
+	// Metrics of demo runs are pushed to the Pushgateway, if configured
+	bindEnv(constants.MetricsPushURLKey, constants.MetricsPushEnv)
+	viper.SetDefault(constants.MetricsJobKey, constants.AppName)
+
+	demoCmd.PersistentFlags().StringVar(&demoCmdArgs.ReportFile, "report",
+		"", "write a run report to the specified file")
+	demoCmd.PersistentFlags().StringVar(&demoCmdArgs.ReportFormat, "report-format",
+		report.FormatJSON, "set the run report format [json|junit]")
 
 	rootCmd.AddCommand(demoCmd)
 }
+
+// finishReport completes the run report and writes it to the file
+// specified by the --report flag, if any.
+func finishReport(rpt *report.Report) {
+	rpt.Finish()
+	metrics.ObserveReport(rpt)
+	pushMetrics(rpt.Command)
+	if demoCmdArgs.ReportFile == "" {
+		return
+	}
+
+	err := rpt.Write(demoCmdArgs.ReportFile, demoCmdArgs.ReportFormat)
+	if err != nil {
+		log.Error("unable to write run report: ", err)
+		return
+	}
+	log.WithField("path", demoCmdArgs.ReportFile).Info("wrote run report")
+}
+
+// pushMetrics pushes the metrics of a command run to the Pushgateway,
+// if one is configured, logging any failure. The metrics are grouped by
+// command, namespace and release so that runs replace earlier ones.
+func pushMetrics(command string) {
+	url := viper.GetString(constants.MetricsPushURLKey)
+	if url == "" {
+		return
+	}
+
+	err := metrics.Push(url, viper.GetString(constants.MetricsJobKey), map[string]string{
+		"operation": strings.ReplaceAll(command, " ", "-"),
+		"namespace": viper.GetString(constants.GlobalNamespaceKey),
+		"release":   viper.GetString(constants.GlobalReleaseKey),
+	})
+	if err != nil {
+		log.Warn("unable to push metrics: ", err)
+		return
+	}
+	log.WithField("url", url).Debug("pushed metrics")
+}
@@ -23,8 +23,12 @@ import (
 
 	"github.com/go-http-utils/headers"
 	"github.com/pkg/errors"
+	"github.com/robertwtucker/spt-util/pkg/config"
 	"github.com/robertwtucker/spt-util/pkg/constants"
 	"github.com/robertwtucker/spt-util/pkg/eventbus"
+	"github.com/robertwtucker/spt-util/pkg/metrics"
+	"github.com/robertwtucker/spt-util/pkg/report"
+	"github.com/robertwtucker/spt-util/pkg/tracing"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -54,12 +58,36 @@ type EventData struct {
 	WorkflowsToDeploy     []Workflow
 }
 
+// redacted returns a copy of the event data that is safe to log.
+func (d *EventData) redacted() EventData {
+	redacted := *d
+	redacted.AuthHeader = config.Redacted
+	return redacted
+}
+
 // initCmd represents the init command.
 var initCmd = &cobra.Command{
-	Use:   "init",
-	Short: "Initializes a demo instance",
+	Use:         "init",
+	Short:       "Initializes a demo instance",
+	Annotations: map[string]string{scalerAnnotation: "true"},
 	Long: `
 Initializes a demo instance given the specified release and namespace.
+
+The Scaler credentials (demo.username, demo.password or SCALER_USER and
+SCALER_PASS) may be given as secret references, file:///path/to/secret or
+env:NAME, or be read from the username and password keys of a mounted
+Kubernetes secret directory (demo.secretDir or SCALER_SECRET_DIR).
+
+The Scaler URL and credentials that are not configured are discovered from
+the Service, ConfigMap and Secret of the Helm release (global.release or
+--release) in the cluster namespace (global.namespace or --namespace), unless
+kubernetes.discover is false.
+
+Before initializing, it waits for Scaler to be ready as 'spt-util scaler wait'
+does, unless demo.wait.enabled is false.
+
+If events.file is set, the events of the run are recorded in that file, with
+secrets redacted, for 'spt-util events show'.
     `,
 	Example: `
 # initialize base content for a demo environment with debug logging enabled
@@ -67,47 +95,20 @@ spt-util demo init -d
 	`,
 	Run: func(cmd *cobra.Command, args []string) {
 		log.Info("starting demo environment initialization")
+		rpt := report.New("demo init")
 
-		// Setup context data
-		var data = &EventData{
-			AuthHeader: fmt.Sprintf(
-				"Basic %s",
-				getBasicAuthEncoding(
-					viper.GetString(constants.DemoUsernameKey),
-					viper.GetString(constants.DemoPasswordKey),
-				),
-			),
-			ChsFilePath:         viper.GetString(constants.DemoInitChsFileKey),
-			EnvFilePath:         viper.GetString(constants.DemoInitEnvFileKey),
-			Namespace:           viper.GetString(constants.GlobalNamespaceKey),
-			Release:             viper.GetString(constants.GlobalReleaseKey),
-			ScalerHost:          viper.GetString(constants.DemoServerKey),
-			TargetWorkflowNames: viper.GetStringSlice(constants.DemoInitWorkflowsKey),
-			WorkflowsToDeploy:   []Workflow{},
+		if viper.GetBool(constants.DemoWaitEnabledKey) {
+			if err := runWait(cmd.Context(), rpt); err != nil {
+				finishReport(rpt)
+				log.Fatalf("error waiting for Scaler: %s", err)
+			}
 		}
-		data.StartingWorkflowCount = getScalerWorkflowCount(data.ScalerHost, data.AuthHeader)
-		log.WithField("data", data).Debug("initial event data")
-
-		// Create an EventBus instance
-		eb := eventbus.NewEventBus()
-
-		// Create event subscriptions
-		chEnv := eb.SubscribeEvent(eventbus.InitStart)
-		chChs := eb.SubscribeEvent(eventbus.InitStart)
-		chFind := eb.SubscribeEvent(eventbus.InitFindScalerWorkflows)
-		chDeploy := eb.SubscribeEvent(eventbus.InitDeployScalerWorkflows)
-
-		// Start goroutines that receive the triggering events
-		go importIcmEnvFile(chEnv, eb)         // <-InitStart
-		go uploadIcmChangeSet(chChs, eb)       // <-InitStart
-		go findScalerWorkflows(chFind, eb)     // <-InitFindScalerWorkflows
-		go deployScalerWorkflows(chDeploy, eb) // <-InitDeployScalerWorkflows
-
-		// Serialize our data and publish the initial event
-		jsonData, _ := json.Marshal(data)
-		log.Debug("publishing start event")
-		eb.PublishEvent(eventbus.InitStart, jsonData)
 
+		err := runInit(cmd.Context(), rpt)
+		finishReport(rpt)
+		if err != nil {
+			log.Fatalf("error initializing demo environment: %s", err)
+		}
 		log.Info("ending demo environment initialization")
 	},
 }
@@ -118,34 +119,43 @@ func init() {
 }
 
 // Import the base set of ICM environment variables.
-func importIcmEnvFile(channel eventbus.EventChannel, _ *eventbus.EventBus) {
-	event := <-channel
-	log.WithField(
+func importIcmEnvFile(
+	ctx context.Context, channel eventbus.EventChannel, _ *eventbus.EventBus, rpt *report.Report,
+) {
+	event, ok := receiveEvent(ctx, channel)
+	if !ok {
+		return
+	}
+	logger := log.WithContext(event.Context())
+	logger.WithField(
 		"event", event.Name,
 	).Debug("received event in importIcmEnvFile")
 	defer event.Done()
+	step := rpt.StartStep("import-icm-environment")
 
 	data := EventData{}
 	if eventData, ok := event.Data.([]byte); ok {
 		_ = json.Unmarshal(eventData, &data)
 	} else {
-		log.Error("error decoding event data: not []byte")
+		logger.Error("error decoding event data: not []byte")
+		step.Finish(errors.New("error decoding event data: not []byte"))
 		return
 	}
 
-	log.WithField(
+	logger.WithField(
 		"path", data.EnvFilePath,
 	).Debug("reading environment file content")
 	envFileContent, err := os.ReadFile(data.EnvFilePath)
 	if err != nil {
-		log.Error("unable to read environment file: ", err)
+		logger.Error("unable to read environment file: ", err)
+		step.Finish(errors.Wrap(err, "unable to read environment file"))
 		return
 	}
 
 	// request
 	// PUT {{baseUrl}}/api/content/v1/inspireEnvironments
 	request, err := http.NewRequestWithContext(
-		context.Background(),
+		event.Context(),
 		http.MethodPut,
 		fmt.Sprintf(
 			"%s/%s",
@@ -155,10 +165,11 @@ func importIcmEnvFile(channel eventbus.EventChannel, _ *eventbus.EventBus) {
 		bytes.NewBuffer(envFileContent),
 	)
 	if err != nil {
-		log.Error("failed to create import environment request: ", err)
+		logger.Error("failed to create import environment request: ", err)
+		step.Finish(errors.Wrap(err, "failed to create import environment request"))
 		return
 	}
-	log.WithFields(log.Fields{
+	logger.WithFields(log.Fields{
 		"method": request.Method,
 		"url":    request.URL,
 	}).Debug("created import environment request")
@@ -167,43 +178,57 @@ func importIcmEnvFile(channel eventbus.EventChannel, _ *eventbus.EventBus) {
 	request.Header.Set(headers.ContentType, "application/json")
 
 	// response
-	log.Info("importing environment variables")
-	//nolint:gomnd // TODO: Externalize constant value in config file.
-	client := &http.Client{Timeout: time.Second * 5}
+	logger.Info("importing environment variables")
+	client := newScalerClient()
 	response, err := client.Do(request)
 	if err != nil {
-		log.Error("failed to send import environment request: ", err)
+		logger.Error("failed to send import environment request: ", err)
+		step.Finish(errors.Wrap(err, "failed to send import environment request"))
 		return
 	}
 	defer func() { _ = response.Body.Close }()
+	step.AddHTTPStatus(response.StatusCode)
 
 	// process
 	if response.StatusCode >= http.StatusBadRequest {
 		body, _ := io.ReadAll(response.Body)
-		log.Errorf(
+		logger.Errorf(
 			"received non-ok HTTP status importing environment variables: [%d]:%s",
 			response.StatusCode,
 			string(body),
 		)
+		step.Finish(errors.Errorf(
+			"received non-ok HTTP status importing environment variables: [%d]",
+			response.StatusCode,
+		))
 		return
 	}
 
-	log.Info("environment variables imported successfully")
+	logger.Info("environment variables imported successfully")
+	step.Finish(nil)
 }
 
 // Upload changeset w/workflows for rest of process.
-func uploadIcmChangeSet(channel eventbus.EventChannel, eb *eventbus.EventBus) {
-	event := <-channel
-	log.WithField(
+func uploadIcmChangeSet(
+	ctx context.Context, channel eventbus.EventChannel, eb *eventbus.EventBus, rpt *report.Report,
+) {
+	event, ok := receiveEvent(ctx, channel)
+	if !ok {
+		return
+	}
+	logger := log.WithContext(event.Context())
+	logger.WithField(
 		"event", event.Name,
 	).Debug("received event in uploadIcmChangeSet")
 	defer event.Done()
+	step := rpt.StartStep("upload-icm-changeset")
 
 	data := EventData{}
 	if eventData, ok := event.Data.([]byte); ok {
 		_ = json.Unmarshal(eventData, &data)
 	} else {
-		log.Error("error decoding event data: not []byte")
+		logger.Error("error decoding event data: not []byte")
+		step.Finish(errors.New("error decoding event data: not []byte"))
 		return
 	}
 
@@ -214,58 +239,75 @@ func uploadIcmChangeSet(channel eventbus.EventChannel, eb *eventbus.EventBus) {
 		data.ScalerHost,
 		"api/content/v1/upload/changesets",
 	)
-	request, err := newFileUploadRequest(url, data.ChsFilePath)
+	request, err := newFileUploadRequest(event.Context(), url, data.ChsFilePath)
 	if err != nil {
-		log.Error("error creating upload changeset request: ", err)
+		logger.Error("error creating upload changeset request: ", err)
+		step.Finish(errors.Wrap(err, "error creating upload changeset request"))
 		return
 	}
-	log.WithFields(log.Fields{
+	logger.WithFields(log.Fields{
 		"method": request.Method,
 		"url":    request.URL,
 	}).Debug("created import changeset request")
 	request.Header.Set(headers.Authorization, data.AuthHeader)
 
 	// response
-	log.Info("uploading changeset")
-	//nolint:gomnd // TODO: Externalize constant value in config file.
-	client := &http.Client{Timeout: time.Second * 5}
+	logger.Info("uploading changeset")
+	client := newScalerClient()
 	response, err := client.Do(request)
 	if err != nil {
-		log.Error("error sending import changeset request: ", err)
+		logger.Error("error sending import changeset request: ", err)
+		step.Finish(errors.Wrap(err, "error sending import changeset request"))
 		return
 	}
 	defer func() { _ = response.Body.Close }()
+	step.AddHTTPStatus(response.StatusCode)
 
 	// process
 	if response.StatusCode >= http.StatusBadRequest {
 		body, _ := io.ReadAll(response.Body)
-		log.Errorf(
+		logger.Errorf(
 			"received non-ok HTTP status importing changeset: [%d]:%s",
 			response.StatusCode,
 			string(body),
 		)
+		step.Finish(errors.Errorf(
+			"received non-ok HTTP status importing changeset: [%d]",
+			response.StatusCode,
+		))
 		return
 	}
-	log.Info("changeset uploaded successfully")
+	logger.Info("changeset uploaded successfully")
+	step.Finish(nil)
 
 	// Trigger (publish) the next event process. The serialized
 	// JSON hasn't changed, pass it as-is.
-	eb.PublishEvent(eventbus.InitFindScalerWorkflows, event.Data)
+	if err = eb.PublishEventContext(event.Context(), eventbus.InitFindScalerWorkflows, event.Data); err != nil {
+		logger.Error("error publishing find workflows event: ", err)
+	}
 }
 
 // Find required workflows in Scaler.
-func findScalerWorkflows(channel eventbus.EventChannel, eb *eventbus.EventBus) {
-	event := <-channel
-	log.WithField(
+func findScalerWorkflows(
+	ctx context.Context, channel eventbus.EventChannel, eb *eventbus.EventBus, rpt *report.Report,
+) {
+	event, ok := receiveEvent(ctx, channel)
+	if !ok {
+		return
+	}
+	logger := log.WithContext(event.Context())
+	logger.WithField(
 		"event", event.Name,
 	).Debug("received event in findScalerWorkflows")
 	defer event.Done()
+	step := rpt.StartStep("find-scaler-workflows")
 
 	data := EventData{}
 	if eventData, ok := event.Data.([]byte); ok {
 		_ = json.Unmarshal(eventData, &data)
 	} else {
-		log.Error("error decoding event data: not []byte")
+		logger.Error("error decoding event data: not []byte")
+		step.Finish(errors.New("error decoding event data: not []byte"))
 		return
 	}
 
@@ -275,15 +317,17 @@ func findScalerWorkflows(channel eventbus.EventChannel, eb *eventbus.EventBus) {
 	for {
 		//nolint:gomnd // TODO: Externalize constant value in config file.
 		if tries > 15 {
-			log.Error("exceeded try count waiting for workflows to be applied")
+			logger.Error("exceeded try count waiting for workflows to be applied")
+			step.Finish(errors.New("exceeded try count waiting for workflows to be applied"))
 			return
 		}
 		if currentWorkflowCount > data.StartingWorkflowCount {
-			log.Info("changeset workflows have been applied")
+			logger.Info("changeset workflows have been applied")
 			break
 		}
-		currentWorkflowCount = getScalerWorkflowCount(data.ScalerHost, data.AuthHeader)
-		log.WithFields(log.Fields{
+		// Listing errors are logged and retried.
+		currentWorkflowCount, _ = getScalerWorkflowCount(event.Context(), data.ScalerHost, data.AuthHeader, step)
+		logger.WithFields(log.Fields{
 			"workflows": currentWorkflowCount,
 			"retries":   tries,
 		}).Info("waiting for new workflows")
@@ -295,23 +339,23 @@ func findScalerWorkflows(channel eventbus.EventChannel, eb *eventbus.EventBus) {
 	// Find the required workflows.
 	targetWorkflowNames := data.TargetWorkflowNames
 	targetWorkflowCount := sort.StringSlice.Len(targetWorkflowNames)
-	log.Debug("# target workflows: ", targetWorkflowCount)
+	logger.Debug("# target workflows: ", targetWorkflowCount)
 
 	// sort.SearchStrings() used below expects a sorted slice.
 	if targetWorkflowCount > 1 {
 		sort.StringSlice(targetWorkflowNames).Sort()
 	}
 
-	workflows, err := getScalerWorkflows(data.ScalerHost, data.AuthHeader)
+	workflows, err := getScalerWorkflows(event.Context(), data.ScalerHost, data.AuthHeader, step)
 	if err != nil {
-		log.Error("failed to get workflows to inspect: ", err)
+		logger.Error("failed to get workflows to inspect: ", err)
 	}
 
 	deployable := []Workflow{}
 	for _, workflow := range workflows {
 		if index := sort.SearchStrings(targetWorkflowNames, workflow.Name); index < targetWorkflowCount {
 			if workflow.Name == targetWorkflowNames[index] {
-				log.WithFields(log.Fields{
+				logger.WithFields(log.Fields{
 					"name": workflow.Name,
 					"id":   workflow.ID,
 				}).Debug("matched workflow")
@@ -325,7 +369,8 @@ func findScalerWorkflows(channel eventbus.EventChannel, eb *eventbus.EventBus) {
 	}
 
 	workflowsToDeployCount := len(deployable)
-	log.Debug("# workflows found: ", workflowsToDeployCount)
+	logger.Debug("# workflows found: ", workflowsToDeployCount)
+	step.Finish(err)
 
 	// Add workflows to our data structure.
 	data.WorkflowsToDeploy = deployable
@@ -333,32 +378,43 @@ func findScalerWorkflows(channel eventbus.EventChannel, eb *eventbus.EventBus) {
 	jsonData, _ := json.Marshal(data)
 
 	// Trigger (publish) the next event process..
-	eb.PublishEvent(eventbus.InitDeployScalerWorkflows, jsonData)
+	if err = eb.PublishEventContext(event.Context(), eventbus.InitDeployScalerWorkflows, jsonData); err != nil {
+		logger.Error("error publishing deploy workflows event: ", err)
+	}
 }
 
 // Deploy the required workflows in Scaler.
-func deployScalerWorkflows(channel eventbus.EventChannel, _ *eventbus.EventBus) {
-	event := <-channel
-	log.WithField(
+func deployScalerWorkflows(
+	ctx context.Context, channel eventbus.EventChannel, _ *eventbus.EventBus, rpt *report.Report,
+) {
+	event, ok := receiveEvent(ctx, channel)
+	if !ok {
+		return
+	}
+	logger := log.WithContext(event.Context())
+	logger.WithField(
 		"event", event.Name,
 	).Debug("received event in deployScalerWorkflows")
 	defer event.Done()
+	step := rpt.StartStep("deploy-scaler-workflows")
 
 	data := EventData{}
 	if eventData, ok := event.Data.([]byte); ok {
 		_ = json.Unmarshal(eventData, &data)
 	} else {
-		log.Error("event data not []byte format")
+		logger.Error("event data not []byte format")
+		step.Finish(errors.New("event data not []byte format"))
 		return
 	}
 
 	jsonBody, _ := json.Marshal(map[string]string{"status": "DEPLOYED"})
 
+	var failed int
 	for _, workflow := range data.WorkflowsToDeploy {
 		// request
 		// PATCH {{baseUrl}}/api/integration/v2/workflows/{id}/
 		request, err := http.NewRequestWithContext(
-			context.Background(),
+			event.Context(),
 			http.MethodPatch,
 			fmt.Sprintf(
 				"%s/%s/%s",
@@ -369,10 +425,11 @@ func deployScalerWorkflows(channel eventbus.EventChannel, _ *eventbus.EventBus)
 			bytes.NewBuffer(jsonBody),
 		)
 		if err != nil {
-			log.Error("failed to create workflow deployment request: ", err)
+			logger.Error("failed to create workflow deployment request: ", err)
+			failed++
 			continue
 		}
-		log.WithFields(log.Fields{
+		logger.WithFields(log.Fields{
 			"method":   request.Method,
 			"url":      request.URL,
 			"workflow": workflow,
@@ -382,23 +439,24 @@ func deployScalerWorkflows(channel eventbus.EventChannel, _ *eventbus.EventBus)
 		request.Header.Set(headers.ContentType, "application/json")
 
 		// response
-		log.WithFields(log.Fields{
+		logger.WithFields(log.Fields{
 			"id":   workflow.ID,
 			"name": workflow.Name,
 		}).Info("sending workflow deployment request")
-		//nolint:gomnd // TODO: Externalize constant value in config file.
-		client := &http.Client{Timeout: time.Second * 5}
+		client := newScalerClient()
 		response, err := client.Do(request)
 		if err != nil {
-			log.Error("failed to send workflow deployment request: ", err)
+			logger.Error("failed to send workflow deployment request: ", err)
+			step.Finish(errors.Wrap(err, "failed to send workflow deployment request"))
 			return
 		}
 		defer func() { _ = response.Body.Close() }()
+		step.AddHTTPStatus(response.StatusCode)
 
 		// process
 		if response.StatusCode >= http.StatusBadRequest {
 			body, _ := io.ReadAll(response.Body)
-			log.WithFields(log.Fields{
+			logger.WithFields(log.Fields{
 				"id":   workflow.ID,
 				"name": workflow.Name,
 			}).Errorf(
@@ -406,15 +464,107 @@ func deployScalerWorkflows(channel eventbus.EventChannel, _ *eventbus.EventBus)
 				response.StatusCode,
 				string(body),
 			)
+			failed++
 			continue
 		}
-		log.WithFields(log.Fields{
+		logger.WithFields(log.Fields{
 			"id":   workflow.ID,
 			"name": workflow.Name,
 		}).Info("workflow deployed successfully")
+		step.AddWorkflow(workflow.Name)
+		metrics.WorkflowsDeployed.Inc()
+	}
+
+	logger.Info("completed Scaler workflow deployment")
+	if failed > 0 {
+		step.Finish(errors.Errorf("failed to deploy %d workflow(s)", failed))
+	} else {
+		step.Finish(nil)
+	}
+}
+
+// runInit imports the ICM environment and changeset and deploys the
+// Scaler workflows, recording each step in the run report. Its Scaler
+// requests and events are traced as part of the span in ctx.
+func runInit(ctx context.Context, rpt *report.Report) error {
+	// Setup context data
+	var data = &EventData{
+		AuthHeader:          scalerAuthHeader(),
+		ChsFilePath:         viper.GetString(constants.DemoInitChsFileKey),
+		EnvFilePath:         viper.GetString(constants.DemoInitEnvFileKey),
+		Namespace:           viper.GetString(constants.GlobalNamespaceKey),
+		Release:             viper.GetString(constants.GlobalReleaseKey),
+		ScalerHost:          viper.GetString(constants.DemoServerKey),
+		TargetWorkflowNames: viper.GetStringSlice(constants.DemoInitWorkflowsKey),
+		WorkflowsToDeploy:   []Workflow{},
+	}
+	step := rpt.StartStep("count-scaler-workflows")
+	count, err := getScalerWorkflowCount(ctx, data.ScalerHost, data.AuthHeader, step)
+	data.StartingWorkflowCount = count
+	step.Finish(err)
+	log.WithContext(ctx).WithField("data", data.redacted()).Debug("initial event data")
+
+	// Create an EventBus instance, recording its events if configured
+	eb := eventbus.NewEventBus()
+	if store := openEventStore(rpt.Command); store != nil {
+		eb.SetStore(store)
+		defer func() { _ = store.Close() }()
+	}
+
+	// Create event subscriptions
+	chEnv := eb.SubscribeEvent(eventbus.InitStart)
+	chChs := eb.SubscribeEvent(eventbus.InitStart)
+	chFind := eb.SubscribeEvent(eventbus.InitFindScalerWorkflows)
+	chDeploy := eb.SubscribeEvent(eventbus.InitDeployScalerWorkflows)
+
+	// Start goroutines that receive the triggering events. Those whose
+	// event is not published, e.g. after a failed upload, stop once
+	// runInit returns.
+	handlerCtx, stopHandlers := context.WithCancel(ctx)
+	defer stopHandlers()
+	go importIcmEnvFile(handlerCtx, chEnv, eb, rpt)         // <-InitStart
+	go uploadIcmChangeSet(handlerCtx, chChs, eb, rpt)       // <-InitStart
+	go findScalerWorkflows(handlerCtx, chFind, eb, rpt)     // <-InitFindScalerWorkflows
+	go deployScalerWorkflows(handlerCtx, chDeploy, eb, rpt) // <-InitDeployScalerWorkflows
+
+	// Serialize our data and publish the initial event
+	jsonData, _ := json.Marshal(data)
+	log.WithContext(ctx).Debug("publishing start event")
+	if err := eb.PublishEventContext(ctx, eventbus.InitStart, jsonData); err != nil {
+		return errors.Wrap(err, "error publishing start event")
 	}
 
-	log.Info("completed Scaler workflow deployment")
+	if rpt.Failed() {
+		return errors.New("one or more initialization steps failed")
+	}
+	return nil
+}
+
+// receiveEvent waits for the event triggering a handler, giving up
+// once ctx is done.
+func receiveEvent(ctx context.Context, channel eventbus.EventChannel) (eventbus.Event, bool) {
+	select {
+	case event := <-channel:
+		return event, true
+	case <-ctx.Done():
+		return eventbus.Event{}, false
+	}
+}
+
+// newScalerClient returns an HTTP client for Scaler requests that
+// records their metrics and traces them.
+func newScalerClient() *http.Client {
+	//nolint:gomnd // TODO: Externalize constant value in config file.
+	return &http.Client{Timeout: time.Second * 5, Transport: tracing.Transport(metrics.Transport(nil))}
+}
+
+// scalerAuthHeader returns the Authorization header for the configured
+// Scaler credentials.
+func scalerAuthHeader() string {
+	return "Basic " + getBasicAuthEncoding(
+		viper.GetString(constants.DemoUsernameKey),
+		viper.GetString(constants.DemoPasswordKey),
+	)
 }
 
 // getBasicAuthEncoding returns HTTP Basic auth encoding for
@@ -425,55 +575,59 @@ func getBasicAuthEncoding(user string, password string) string {
 	)
 }
 
-// Returns a count of workflows in Scaler.
-func getScalerWorkflowCount(scalerHost string, authHeader string) int {
-	var workflowCount int
-
-	workflows, err := getScalerWorkflows(scalerHost, authHeader)
+// Returns a count of workflows in Scaler, 0 if they could not be
+// listed.
+func getScalerWorkflowCount(
+	ctx context.Context, scalerHost string, authHeader string, step *report.Step,
+) (int, error) {
+	workflows, err := getScalerWorkflows(ctx, scalerHost, authHeader, step)
 	if err != nil {
-		log.Error("failed to get workflow count: ", err)
-	} else {
-		workflowCount = len(workflows)
+		log.WithContext(ctx).Error("failed to get workflow count: ", err)
+		return 0, err
 	}
 
-	return workflowCount
+	return len(workflows), nil
 }
 
-// Returns a Workflow slice representing the workflows in Scaler.
-func getScalerWorkflows(scalerHost string, authHeader string) ([]Workflow, error) {
+// Returns a Workflow slice representing the workflows in Scaler. HTTP
+// status codes received are recorded in the (optional) report step.
+func getScalerWorkflows(
+	ctx context.Context, scalerHost string, authHeader string, step *report.Step,
+) ([]Workflow, error) {
+	logger := log.WithContext(ctx)
 	// request
 	// GET {{baseUrl}}/api/integration/v2/workflows/
 	request, err := http.NewRequestWithContext(
-		context.Background(),
+		ctx,
 		http.MethodGet,
 		fmt.Sprintf("%s/%s", scalerHost, "api/integration/v2/workflows"),
 		nil,
 	)
 	if err != nil {
-		log.Error("failed to create list workflows request: ", err)
+		logger.Error("failed to create list workflows request: ", err)
 		return []Workflow{}, err
 	}
-	log.WithFields(log.Fields{
+	logger.WithFields(log.Fields{
 		"method": request.Method,
 		"url":    request.URL,
 	}).Debug("created list workflows request")
 	request.Header.Set(headers.Authorization, authHeader)
 
 	// response
-	log.Debug("sending list workflows request")
-	//nolint:gomnd // TODO: Externalize constant value in config file.
-	client := &http.Client{Timeout: time.Second * 5}
+	logger.Debug("sending list workflows request")
+	client := newScalerClient()
 	response, err := client.Do(request)
 	if err != nil {
-		log.Error("failed to send list workflows request: ", err)
+		logger.Error("failed to send list workflows request: ", err)
 		return []Workflow{}, err
 	}
 	defer func() { _ = response.Body.Close }()
+	step.AddHTTPStatus(response.StatusCode)
 
 	// process
 	body, _ := io.ReadAll(response.Body)
 	if response.StatusCode >= http.StatusBadRequest {
-		log.WithField("responseBody", string(body)).Errorf(
+		logger.WithField("responseBody", string(body)).Errorf(
 			"received non-ok HTTP status listing workflows: [%d]:%s",
 			response.StatusCode,
 			string(body),
@@ -483,9 +637,9 @@ func getScalerWorkflows(scalerHost string, authHeader string) ([]Workflow, error
 
 	// Deserialize the JSON response
 	workflowsResponse := WorkflowsResponse{}
-	log.Debug("received list of workflows")
+	logger.Debug("received list of workflows")
 	if err = json.Unmarshal(body, &workflowsResponse); err != nil {
-		log.Error("failed to process JSON in list workflows response:", err)
+		logger.Error("failed to process JSON in list workflows response:", err)
 		return []Workflow{}, err
 	}
 
@@ -493,11 +647,11 @@ func getScalerWorkflows(scalerHost string, authHeader string) ([]Workflow, error
 }
 
 // newFileUploadRequest is a helper for uploading files via HTTP.
-func newFileUploadRequest(uri string, path string) (*http.Request, error) {
-	log.WithField("path", path).Debug("reading upload file content")
+func newFileUploadRequest(ctx context.Context, uri string, path string) (*http.Request, error) {
+	log.WithContext(ctx).WithField("path", path).Debug("reading upload file content")
 	file, err := os.Open(path)
 	if err != nil {
-		log.Error("error opening upload file:", err)
+		log.WithContext(ctx).Error("error opening upload file:", err)
 		return nil, err
 	}
 	defer func() { _ = file.Close() }()
@@ -515,7 +669,7 @@ func newFileUploadRequest(uri string, path string) (*http.Request, error) {
 		return nil, err
 	}
 
-	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, uri, body)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, uri, body)
 	req.Header.Set(headers.ContentType, writer.FormDataContentType())
 	return req, err
 }
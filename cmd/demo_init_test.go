@@ -0,0 +1,62 @@
+//
+// Copyright (c) 2024 Quadient Group AG
+//
+// This file is subject to the terms and conditions defined in the
+// 'LICENSE' file found in the root of this source code package.
+//
+
+package cmd_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/robertwtucker/spt-util/cmd"
+	"github.com/robertwtucker/spt-util/pkg/constants"
+	"github.com/robertwtucker/spt-util/pkg/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// handlersRunning reports whether any demo init event handler is still
+// running.
+func handlersRunning() bool {
+	buf := make([]byte, 1<<20)
+	stacks := string(buf[:runtime.Stack(buf, true)])
+	return strings.Contains(stacks, "cmd.findScalerWorkflows") || strings.Contains(stacks, "cmd.deployScalerWorkflows")
+}
+
+func TestRunInit_FailedUploadStopsHandlers(t *testing.T) {
+	scaler := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/upload/changesets"):
+			w.WriteHeader(http.StatusInternalServerError)
+		case r.Method == http.MethodGet:
+			_, _ = w.Write([]byte(`{"workflows":[]}`))
+		}
+	}))
+	defer scaler.Close()
+
+	dir := t.TempDir()
+	envFile := filepath.Join(dir, "env.json")
+	chsFile := filepath.Join(dir, "import.chs")
+	require.NoError(t, os.WriteFile(envFile, []byte("{}"), 0o600))
+	require.NoError(t, os.WriteFile(chsFile, []byte("chs"), 0o600))
+	setConfig(t, constants.DemoServerKey, scaler.URL)
+	setConfig(t, constants.DemoInitEnvFileKey, envFile)
+	setConfig(t, constants.DemoInitChsFileKey, chsFile)
+	setConfig(t, constants.DemoInitWorkflowsKey, []string{"SPT Import Handler"})
+
+	rpt := report.New("demo init")
+	assert.Error(t, cmd.RunInit(context.Background(), rpt))
+
+	// The handlers waiting for events that are never published stop.
+	assert.Eventually(t, func() bool { return !handlersRunning() }, 5*time.Second, 10*time.Millisecond)
+}
@@ -8,16 +8,27 @@
 package cmd
 
 import (
-	cp "github.com/otiai10/copy"
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
 	"github.com/robertwtucker/spt-util/pkg/constants"
+	"github.com/robertwtucker/spt-util/pkg/metrics"
+	"github.com/robertwtucker/spt-util/pkg/report"
+	"github.com/robertwtucker/spt-util/pkg/stage"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
-type FilesToCopy struct {
-	Source      string `mapstructure:"src"`
-	Destination string `mapstructure:"dest"`
+var stageCmdArgs struct {
+	NoRollback bool
+	Workers    int
+	Watch      bool
 }
 
 // stageCmd represents the stage command.
@@ -25,39 +36,288 @@ var stageCmd = &cobra.Command{
 	Use:   "stage",
 	Short: "Stages demo resources",
 	Long: `
-Stages files and directories for a demo instance
+Stages files and directories for a demo instance. Each entry may specify an
+expected sha256 checksum that is verified after copying, request verification
+against its source (verify: true) and skip files that are unchanged in the
+destination (skipUnchanged: true) by comparing their size, mtime or hash
+(compare: size|mtime|hash).
+
+Archives (zip, tar.gz) can be extracted into the destination directory with
+action: extract, optionally removing leading path elements (stripComponents)
+and filtering the archive's files by include/exclude patterns. Extracted files
+larger than demo.stage.maxEntrySize bytes fail the entry.
+
+Sources may be glob patterns (including ** to match any number of directories),
+in which case the destination is a directory that receives the matching files
+at their relative paths or, with flatten: true, directly.
+
+Staged files and directories can be given an explicit mode (fileMode, dirMode)
+and owner (uid, gid). Existing files are overwritten according to the overwrite
+policy (always|never|if-newer) and symbolic links are handled according to the
+symlinks setting (shallow|deep|skip).
+
+Entries with template: true are rendered through Go's text/template before
+being written, with the configuration (including the --release and --namespace
+overrides) as data, e.g. {{ .global.release }}, {{ .global.namespace }} or
+{{ .demo.server }}. Configuration keys are lower case in this form; the config
+function looks up any key as written, e.g. {{ config "demo.init.envFile" }}.
+
+Sources may also be http(s):// or s3://bucket/key URLs. Remote files are
+downloaded to demo.stage.cacheDir before being staged, each within
+demo.stage.downloadTimeout. Interrupted downloads of entries with a sha256 are
+resumed. HTTP credentials are read from STAGE_HTTP_TOKEN or STAGE_HTTP_USER and
+STAGE_HTTP_PASS; S3 credentials, region and endpoint (e.g. a MinIO server) from
+AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, AWS_REGION and AWS_ENDPOINT_URL_S3.
+
+If any entry fails, files overwritten by the run are restored from backups and
+new files and directories are removed, unless --no-rollback is given.
+
+Entries are staged by demo.stage.workers (or --workers) concurrent workers. The
+progress of large files is reported every demo.stage.progressInterval, as a
+progress bar when running in a terminal, followed by a throughput summary.
+
+With --watch, the local sources are watched after the initial run and entries
+are staged again when their sources change, once no further changes occurred
+for demo.stage.watchDebounce. Watching continues until interrupted.
+
+Every file written is recorded with its source, size, checksum and timestamps
+in the staging manifest (demo.stage.manifest or --manifest), which the clean
+and verify subcommands use to remove the staged files or report drift.
 	`,
 	Example: `
 # stage files in a demo environment using a custom configuration file
 spt-util demo stage -c <path-to-config.yaml>
+
+# stage files again whenever their sources change
+spt-util demo stage --watch
 	`,
 	Run: func(cmd *cobra.Command, args []string) {
 		log.Info("starting demo environment file staging")
+		rpt := report.New("demo stage")
 
-		var files []FilesToCopy
-		err := viper.UnmarshalKey(constants.DemoStageFilesKey, &files)
-		if err != nil {
-			log.Fatal("error getting config file values")
+		files, err := stageFiles(rpt)
+		if err == nil {
+			err = runStage(cmd.Context(), rpt, files)
 		}
-
-		log.Infof("file(s) to process: %d", len(files))
-		for _, f := range files {
-			log.WithFields(log.Fields{
-				"src":  f.Source,
-				"dest": f.Destination,
-			}).Info("copying file")
-
-			err = cp.Copy(f.Source, f.Destination)
+		finishReport(rpt)
+		if !stageCmdArgs.Watch {
 			if err != nil {
-				log.Fatalf("error copying file: %s", err)
+				log.Fatalf("error staging files: %s", err)
 			}
+			log.Info("completed staging demo environment files")
+			return
 		}
 
-		log.Info("completed staging demo environment files")
+		if err != nil {
+			log.Error("error staging files: ", err)
+		}
+		if err = watchStage(files); err != nil {
+			log.Fatalf("error watching files: %s", err)
+		}
 	},
 }
 
 //nolint:gochecknoinits // required for proper cobra initialization.
 func init() {
+	stageCmd.Flags().BoolVar(&stageCmdArgs.NoRollback, "no-rollback",
+		false, "keep the files staged so far when an entry fails")
+	stageCmd.Flags().IntVarP(&stageCmdArgs.Workers, "workers", "w",
+		1, "set the number of entries staged concurrently")
+	stageCmd.Flags().BoolVar(&stageCmdArgs.Watch, "watch",
+		false, "stage entries again when their sources change")
+	bindFlag(constants.DemoStageWorkersKey, stageCmd.Flags().Lookup("workers"))
+	//nolint:gomnd // default reporting interval.
+	viper.SetDefault(constants.DemoStageProgressKey, 5*time.Second)
+	viper.SetDefault(constants.DemoStageTimeoutKey, stage.DefaultDownloadTimeout)
+	viper.SetDefault(constants.DemoStageMaxEntryKey, stage.DefaultMaxEntrySize)
+	stageCmd.PersistentFlags().String("manifest",
+		"", "specify the staging manifest file (default is "+defaultManifestPath()+")")
+	bindFlag(constants.DemoStageManifestKey, stageCmd.PersistentFlags().Lookup("manifest"))
+	viper.SetDefault(constants.DemoStageManifestKey, defaultManifestPath())
+	//nolint:gomnd // default quiet period before staging changes.
+	viper.SetDefault(constants.DemoStageDebounceKey, 500*time.Millisecond)
+
 	demoCmd.AddCommand(stageCmd)
 }
+
+// stageFiles reads the entries to stage from the configuration.
+func stageFiles(rpt *report.Report) ([]stage.FilesToCopy, error) {
+	var files []stage.FilesToCopy
+	if err := viper.UnmarshalKey(constants.DemoStageFilesKey, &files); err != nil {
+		rpt.StartStep("read-stage-config").Finish(err)
+		return nil, errors.Wrap(err, "error getting config file values")
+	}
+	return files, nil
+}
+
+// runStage stages the files, recording each entry in the run report.
+// Unless disabled, a failure rolls back all changes made by the run.
+func runStage(ctx context.Context, rpt *report.Report, files []stage.FilesToCopy) error {
+	logger := log.WithContext(ctx)
+	opts := stage.Options{
+		CacheDir:        viper.GetString(constants.DemoStageCacheDirKey),
+		DownloadTimeout: viper.GetDuration(constants.DemoStageTimeoutKey),
+		MaxEntrySize:    viper.GetInt64(constants.DemoStageMaxEntryKey),
+		Progress:        stage.NewProgress(os.Stderr, viper.GetDuration(constants.DemoStageProgressKey)),
+		// Templates can reference any configuration value.
+		TemplateData: viper.AllSettings(),
+	}
+	if !stageCmdArgs.NoRollback {
+		opts.Transaction = stage.NewTransaction()
+	}
+
+	manifestPath := viper.GetString(constants.DemoStageManifestKey)
+	manifest, err := stage.LoadManifest(manifestPath)
+	if err != nil {
+		rpt.StartStep("read-manifest").Finish(err)
+		return err
+	}
+
+	workers := viper.GetInt(constants.DemoStageWorkersKey)
+	logger.WithField("workers", workers).Infof("file(s) to process: %d", len(files))
+	opts.Progress.Start()
+	err = stageEntries(ctx, rpt, files, opts, manifest, workers)
+	summary := opts.Progress.Stop()
+	metrics.BytesStaged.Add(float64(summary.Bytes))
+	metrics.BytesDownloaded.Add(float64(summary.Downloaded))
+	logger.WithFields(log.Fields{
+		"bytes":      summary.Bytes,
+		"downloaded": summary.Downloaded,
+		"duration":   summary.Duration.Round(time.Millisecond).String(),
+		"throughput": stage.FormatBytes(int64(summary.Throughput)) + "/s",
+	}).Info("staging summary")
+
+	if err != nil {
+		if opts.Transaction != nil {
+			logger.Warn("rolling back staged files")
+			step := rpt.StartStep("rollback")
+			step.Finish(opts.Transaction.Rollback())
+			return err
+		}
+		saveManifest(ctx, manifest, manifestPath)
+		return err
+	}
+
+	if err = opts.Transaction.Commit(); err != nil {
+		logger.Warn("unable to clean up after staging: ", err)
+	}
+	saveManifest(ctx, manifest, manifestPath)
+	return nil
+}
+
+// saveManifest writes the staging manifest, logging any failure.
+func saveManifest(ctx context.Context, manifest *stage.Manifest, path string) {
+	logger := log.WithContext(ctx)
+	if err := manifest.Save(path); err != nil {
+		logger.Error("unable to write staging manifest: ", err)
+		return
+	}
+	logger.WithField("path", path).Debug("wrote staging manifest")
+}
+
+// watchStage stages the entries again whenever their sources change,
+// until the process is interrupted. Each run writes a new report.
+func watchStage(files []stage.FilesToCopy) error {
+	watcher, err := stage.NewWatcher(files, viper.GetDuration(constants.DemoStageDebounceKey))
+	if err != nil {
+		return err
+	}
+	defer func() { _ = watcher.Close() }()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	log.Info("watching sources for changes, press Ctrl+C to stop")
+	err = watcher.Watch(ctx, func(changed []stage.FilesToCopy) {
+		log.Infof("source(s) changed, restaging %d file(s)", len(changed))
+		rpt := report.New("demo stage")
+		if err := runStage(ctx, rpt, changed); err != nil {
+			log.Error("error staging files: ", err)
+		} else {
+			log.Info("completed staging changed files")
+		}
+		finishReport(rpt)
+	})
+	log.Info("stopped watching sources")
+	return err
+}
+
+// stageEntries stages the entries using the given number of concurrent
+// workers. After the first failure no further entries are started and
+// the first error is returned once the running entries have finished.
+func stageEntries(
+	ctx context.Context, rpt *report.Report, files []stage.FilesToCopy, opts stage.Options,
+	manifest *stage.Manifest, workers int,
+) error {
+	if workers < 1 {
+		workers = 1
+	}
+
+	entries := make(chan stage.FilesToCopy)
+	var firstErr error
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for f := range entries {
+				if err := stageEntry(ctx, rpt, f, opts, manifest); err != nil {
+					mutex.Lock()
+					if firstErr == nil {
+						firstErr = err
+					}
+					mutex.Unlock()
+				}
+			}
+		}()
+	}
+
+	for _, f := range files {
+		mutex.Lock()
+		failed := firstErr != nil
+		mutex.Unlock()
+		if failed {
+			break
+		}
+		entries <- f
+	}
+	close(entries)
+	wg.Wait()
+
+	return firstErr
+}
+
+// stageEntry stages a single entry and records its result in the run
+// report and the staging manifest.
+func stageEntry(
+	ctx context.Context, rpt *report.Report, f stage.FilesToCopy, opts stage.Options, manifest *stage.Manifest,
+) error {
+	logger := log.WithContext(ctx)
+	logger.WithFields(log.Fields{
+		"src":    f.Source,
+		"dest":   f.Destination,
+		"action": f.Action,
+	}).Info("staging file")
+
+	step := rpt.StartStep("stage " + f.Source)
+	result, err := stage.Stage(f, opts)
+	if result != nil {
+		for _, path := range result.Copied {
+			step.AddFile(path)
+		}
+		if len(result.Copied) == 0 && len(result.Skipped) > 0 {
+			logger.WithField("dest", f.Destination).Info("skipped unchanged file(s)")
+			step.Skip("unchanged")
+		}
+	}
+	// Only entries staged completely are recorded, also with --no-rollback.
+	if err == nil {
+		if recordErr := manifest.Record(f.Source, result); recordErr != nil {
+			logger.Warn("unable to record staged files in manifest: ", recordErr)
+		}
+	}
+	step.Finish(err)
+
+	return err
+}
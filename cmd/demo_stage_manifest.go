@@ -0,0 +1,150 @@
+//
+// Copyright (c) 2024 Quadient Group AG
+//
+// This file is subject to the terms and conditions defined in the
+// 'LICENSE' file found in the root of this source code package.
+//
+
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/robertwtucker/spt-util/pkg/constants"
+	"github.com/robertwtucker/spt-util/pkg/report"
+	"github.com/robertwtucker/spt-util/pkg/stage"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// stageCleanCmd represents the stage clean command.
+var stageCleanCmd = &cobra.Command{
+	Use:   "clean",
+	Short: "Removes staged demo resources",
+	Long: `
+Removes exactly the files recorded in the staging manifest. Files are dropped
+from the manifest once removed; other files in the destination directories are
+left untouched.
+	`,
+	Example: `
+# remove the files staged in a demo environment
+spt-util demo stage clean
+	`,
+	Run: func(cmd *cobra.Command, args []string) {
+		rpt := report.New("demo stage clean")
+		err := runStageClean(cmd.Context(), rpt)
+		finishReport(rpt)
+		if err != nil {
+			log.Fatalf("error cleaning staged files: %s", err)
+		}
+	},
+}
+
+// stageVerifyCmd represents the stage verify command.
+var stageVerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Verifies staged demo resources",
+	Long: `
+Compares the files recorded in the staging manifest with the file system and
+reports files that are missing or whose size or content changed since they were
+staged. Exits with an error if any drift is found.
+	`,
+	Example: `
+# check a demo environment for changes to staged files
+spt-util demo stage verify
+	`,
+	Run: func(cmd *cobra.Command, args []string) {
+		rpt := report.New("demo stage verify")
+		err := runStageVerify(cmd.Context(), rpt, nil)
+		finishReport(rpt)
+		if err != nil {
+			log.Fatalf("error verifying staged files: %s", err)
+		}
+	},
+}
+
+//nolint:gochecknoinits // required for proper cobra initialization.
+func init() {
+	stageCmd.AddCommand(stageCleanCmd)
+	stageCmd.AddCommand(stageVerifyCmd)
+}
+
+// defaultManifestPath returns the staging manifest location used when
+// none is configured.
+func defaultManifestPath() string {
+	return filepath.Join(os.TempDir(), constants.AppName, "stage-manifest.json")
+}
+
+// runStageClean removes the files listed in the staging manifest.
+func runStageClean(ctx context.Context, rpt *report.Report) error {
+	logger := log.WithContext(ctx)
+	path := viper.GetString(constants.DemoStageManifestKey)
+	step := rpt.StartStep("clean-staged-files")
+	manifest, err := stage.LoadManifest(path)
+	if err != nil {
+		step.Finish(err)
+		return err
+	}
+	logger.WithField("manifest", path).Infof("file(s) to remove: %d", len(manifest.Files))
+
+	removed, err := manifest.Clean()
+	for _, file := range removed {
+		step.AddFile(file)
+	}
+	if saveErr := manifest.Save(path); saveErr != nil && err == nil {
+		err = saveErr
+	}
+	step.Finish(err)
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("removed %d staged file(s)", len(removed))
+	return nil
+}
+
+// runStageVerify reports drift between the staging manifest and the
+// file system. If files is not nil, only the files staged by these
+// entries are verified.
+func runStageVerify(ctx context.Context, rpt *report.Report, files []stage.FilesToCopy) error {
+	logger := log.WithContext(ctx)
+	path := viper.GetString(constants.DemoStageManifestKey)
+	step := rpt.StartStep("verify-staged-files")
+	manifest, err := stage.LoadManifest(path)
+	if err != nil {
+		step.Finish(err)
+		return err
+	}
+	if files != nil {
+		sources := make([]string, len(files))
+		for i, f := range files {
+			sources[i] = f.Source
+		}
+		manifest = manifest.Select(sources)
+	}
+	logger.WithField("manifest", path).Infof("file(s) to verify: %d", len(manifest.Files))
+
+	drift, err := manifest.Verify()
+	for _, d := range drift {
+		logger.WithFields(log.Fields{
+			"path":    d.Path,
+			"drift":   d.Kind,
+			"details": d.Details,
+		}).Warn("staged file changed")
+		step.AddFile(d.Path)
+	}
+	if err == nil && len(drift) > 0 {
+		err = errors.Errorf("%d of %d staged file(s) changed", len(drift), len(manifest.Files))
+	}
+	step.Finish(err)
+	if err != nil {
+		return err
+	}
+
+	logger.Info("staged files match the manifest")
+	return nil
+}
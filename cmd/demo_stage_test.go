@@ -0,0 +1,114 @@
+//
+// Copyright (c) 2024 Quadient Group AG
+//
+// This file is subject to the terms and conditions defined in the
+// 'LICENSE' file found in the root of this source code package.
+//
+
+package cmd_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/robertwtucker/spt-util/cmd"
+	"github.com/robertwtucker/spt-util/pkg/constants"
+	"github.com/robertwtucker/spt-util/pkg/report"
+	"github.com/robertwtucker/spt-util/pkg/stage"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setConfig sets a configuration value for the duration of the test.
+func setConfig(t *testing.T, key string, value interface{}) {
+	t.Helper()
+	previous := viper.Get(key)
+	viper.Set(key, value)
+	t.Cleanup(func() { viper.Set(key, previous) })
+}
+
+// stageSetup writes n source directories holding a file each and
+// returns the entries staging them into one destination directory,
+// which holds an existing file.
+func stageSetup(t *testing.T, n int, workers int) ([]stage.FilesToCopy, string) {
+	t.Helper()
+	dir := t.TempDir()
+	setConfig(t, constants.DemoStageManifestKey, filepath.Join(dir, "manifest.json"))
+	setConfig(t, constants.DemoStageWorkersKey, workers)
+
+	dest := filepath.Join(dir, "dest")
+	require.NoError(t, os.MkdirAll(dest, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dest, "file-0.txt"), []byte("old"), 0o600))
+
+	files := make([]stage.FilesToCopy, n)
+	for i := range files {
+		src := filepath.Join(dir, "src", fmt.Sprint(i))
+		require.NoError(t, os.MkdirAll(src, 0o755))
+		name := fmt.Sprintf("file-%d.txt", i)
+		require.NoError(t, os.WriteFile(filepath.Join(src, name), []byte(name), 0o600))
+		files[i] = stage.FilesToCopy{Source: src, Destination: dest}
+	}
+	return files, dest
+}
+
+func TestRunStage_Workers(t *testing.T) {
+	for _, workers := range []int{0, 1, 8} {
+		t.Run(fmt.Sprintf("%d workers", workers), func(t *testing.T) {
+			files, dest := stageSetup(t, 300, workers)
+
+			rpt := report.New("demo stage")
+			require.NoError(t, cmd.RunStage(context.Background(), rpt, files))
+			assert.Len(t, rpt.Snapshot().Steps, len(files))
+
+			// Every file arrives, without staging files left behind.
+			entries, err := os.ReadDir(dest)
+			require.NoError(t, err)
+			assert.Len(t, entries, len(files))
+			for i := range files {
+				name := fmt.Sprintf("file-%d.txt", i)
+				content, err := os.ReadFile(filepath.Join(dest, name))
+				require.NoError(t, err)
+				assert.Equal(t, name, string(content))
+			}
+		})
+	}
+}
+
+func TestRunStage_Rollback(t *testing.T) {
+	files, dest := stageSetup(t, 100, 8)
+	files[50].Source += ".missing"
+
+	rpt := report.New("demo stage")
+	assert.Error(t, cmd.RunStage(context.Background(), rpt, files))
+	assert.True(t, rpt.Failed())
+
+	// The existing file is restored and the new ones removed.
+	entries, err := os.ReadDir(dest)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+	content, err := os.ReadFile(filepath.Join(dest, "file-0.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "old", string(content))
+}
+
+func TestRunStage_StopsAfterFailure(t *testing.T) {
+	files, _ := stageSetup(t, 10, 1)
+	files[1].Source += ".missing"
+
+	rpt := report.New("demo stage")
+	assert.Error(t, cmd.RunStage(context.Background(), rpt, files))
+
+	// The entry handed to the worker while the failing one ran may
+	// still be staged, but no further ones.
+	steps := 0
+	for _, step := range rpt.Snapshot().Steps {
+		if step.Name != "rollback" {
+			steps++
+		}
+	}
+	assert.LessOrEqual(t, steps, 3)
+}
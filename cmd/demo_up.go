@@ -0,0 +1,163 @@
+//
+// Copyright (c) 2024 Quadient Group AG
+//
+// This file is subject to the terms and conditions defined in the
+// 'LICENSE' file found in the root of this source code package.
+//
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/robertwtucker/spt-util/pkg/constants"
+	"github.com/robertwtucker/spt-util/pkg/report"
+	"github.com/robertwtucker/spt-util/pkg/stage"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// maxTerminationMessage is the size limit Kubernetes applies to
+// termination messages.
+const maxTerminationMessage = 4096
+
+var upCmdArgs struct {
+	TerminationLog string
+	SkipVerify     bool
+}
+
+// upCmd represents the up command.
+var upCmd = &cobra.Command{
+	Use:         "up",
+	Short:       "Stages and initializes a demo instance",
+	Annotations: map[string]string{scalerAnnotation: "true"},
+	Long: `
+Brings up a demo instance in a single run, e.g. as a Kubernetes Job or init
+container: stages the demo files, waits for Scaler to be ready (unless
+demo.wait.enabled is false), initializes the demo and verifies the files staged.
+The run stops at the first step that fails; all steps are recorded in a single
+run report (--report).
+
+On failure, a summary is written to the termination log (--termination-log),
+if it exists, for 'kubectl describe pod' to show. The exit code tells which
+step failed:
+
+  1  unexpected failure
+  2  invalid configuration (retrying will not help)
+  3  Scaler not ready within demo.wait.timeout
+  4  staging failed
+  5  initialization failed
+  6  staged files changed before the run completed
+
+For example, a Job can fail without further retries on configuration errors:
+
+  podFailurePolicy:
+    rules:
+      - action: FailJob
+        onExitCodes:
+          operator: In
+          values: [2]
+	`,
+	Example: `
+# bring up a demo environment and write a JUnit run report
+spt-util demo up --report /reports/up.xml --report-format junit
+	`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true // logged below
+
+		log.Info("starting demo environment bring-up")
+		rpt := report.New("demo up")
+		err := runUp(cmd.Context(), rpt)
+		finishReport(rpt)
+		if err != nil {
+			log.Error("error bringing up demo environment: ", err)
+			return err
+		}
+		log.Info("demo environment is up")
+		return nil
+	},
+}
+
+//nolint:gochecknoinits // required for proper cobra initialization.
+func init() {
+	upCmd.Flags().StringVar(&upCmdArgs.TerminationLog, "termination-log",
+		"/dev/termination-log", "specify the file receiving the failure summary")
+	upCmd.Flags().BoolVar(&upCmdArgs.SkipVerify, "skip-verify",
+		false, "skip verifying the staged files")
+
+	demoCmd.AddCommand(upCmd)
+}
+
+// runUp runs the steps bringing up a demo environment and returns the
+// first failure, annotated with its exit code.
+func runUp(ctx context.Context, rpt *report.Report) error {
+	if viper.GetString(constants.DemoServerKey) == "" {
+		err := errors.Errorf("no Scaler URL configured (%s or %s)", constants.DemoServerKey, constants.DemoServerEnv)
+		rpt.StartStep("validate-config").Finish(err)
+		return withExitCode(exitConfig, "validate-config", err)
+	}
+
+	files, err := stageFiles(rpt)
+	if err == nil {
+		err = runStage(ctx, rpt, files)
+	}
+	if err != nil {
+		return withExitCode(exitStage, "stage", err)
+	}
+
+	if viper.GetBool(constants.DemoWaitEnabledKey) {
+		if err = runWait(ctx, rpt); err != nil {
+			return withExitCode(exitNotReady, "wait", err)
+		}
+	} else {
+		rpt.StartStep("wait-for-scaler").Skip(constants.DemoWaitEnabledKey + " is false")
+	}
+
+	if err = runInit(ctx, rpt); err != nil {
+		return withExitCode(exitInit, "init", err)
+	}
+
+	if upCmdArgs.SkipVerify {
+		rpt.StartStep("verify-staged-files").Skip("--skip-verify given")
+		return nil
+	}
+	// Files left by earlier runs are not this run's concern.
+	if files == nil {
+		files = []stage.FilesToCopy{}
+	}
+	return withExitCode(exitVerify, "verify", runStageVerify(ctx, rpt, files))
+}
+
+// writeTerminationMessage writes a summary of a failed run to the
+// termination log. The default log is only written if it exists, i.e.
+// when running in a Kubernetes container.
+func writeTerminationMessage(err error) {
+	path := upCmdArgs.TerminationLog
+	if path == "" {
+		return
+	}
+	if flag := upCmd.Flags().Lookup("termination-log"); !flag.Changed {
+		if _, statErr := os.Stat(path); statErr != nil {
+			return
+		}
+	}
+
+	message := fmt.Sprintf("demo up failed (exit code %d): %s", exitCode(err), err)
+	var exitErr *exitError
+	if errors.As(err, &exitErr) && exitErr.step != "" {
+		message = fmt.Sprintf("demo up failed at %s (exit code %d): %s", exitErr.step, exitErr.code, err)
+	}
+	if len(message) > maxTerminationMessage {
+		message = message[:maxTerminationMessage]
+	}
+
+	//nolint:gosec // the termination log is read by the kubelet.
+	if writeErr := os.WriteFile(path, []byte(message), 0o644); writeErr != nil {
+		log.Warn("unable to write termination message: ", writeErr)
+	}
+}
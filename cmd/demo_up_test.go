@@ -0,0 +1,233 @@
+//
+// Copyright (c) 2024 Quadient Group AG
+//
+// This file is subject to the terms and conditions defined in the
+// 'LICENSE' file found in the root of this source code package.
+//
+
+package cmd_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/robertwtucker/spt-util/cmd"
+	"github.com/robertwtucker/spt-util/pkg/constants"
+	"github.com/robertwtucker/spt-util/pkg/report"
+	"github.com/robertwtucker/spt-util/pkg/stage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeScaler is a Scaler instance whose workflow appears once the
+// changeset is uploaded.
+type fakeScaler struct {
+	healthStatus int
+	uploadStatus int
+	onUpload     func()
+	uploaded     int32
+}
+
+func (s *fakeScaler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case strings.HasSuffix(r.URL.Path, "/actuator/health"):
+		w.WriteHeader(s.healthStatus)
+	case strings.HasSuffix(r.URL.Path, "/upload/changesets"):
+		if s.onUpload != nil {
+			s.onUpload()
+		}
+		atomic.StoreInt32(&s.uploaded, 1)
+		w.WriteHeader(s.uploadStatus)
+	case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/workflows"):
+		if atomic.LoadInt32(&s.uploaded) == 1 {
+			_, _ = w.Write([]byte(`{"workflows":[{"id":"1","name":"SPT Import Handler"}]}`))
+		} else {
+			_, _ = w.Write([]byte(`{"workflows":[]}`))
+		}
+	}
+}
+
+// upSetup configures demo up to stage a file, wait for and initialize
+// the Scaler instance and returns the staged file.
+func upSetup(t *testing.T, scaler *fakeScaler) string {
+	t.Helper()
+	dir := t.TempDir()
+	server := httptest.NewServer(scaler)
+	t.Cleanup(server.Close)
+
+	src := filepath.Join(dir, "src")
+	dest := filepath.Join(dir, "dest")
+	require.NoError(t, os.MkdirAll(src, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "foo.txt"), []byte("foo"), 0o600))
+	setConfig(t, constants.DemoStageFilesKey, []stage.FilesToCopy{{Source: src, Destination: dest}})
+	setConfig(t, constants.DemoStageManifestKey, filepath.Join(dir, "manifest.json"))
+	setConfig(t, constants.DemoStageWorkersKey, 1)
+
+	envFile := filepath.Join(dir, "env.json")
+	chsFile := filepath.Join(dir, "import.chs")
+	require.NoError(t, os.WriteFile(envFile, []byte("{}"), 0o600))
+	require.NoError(t, os.WriteFile(chsFile, []byte("chs"), 0o600))
+	setConfig(t, constants.DemoServerKey, server.URL)
+	setConfig(t, constants.DemoInitEnvFileKey, envFile)
+	setConfig(t, constants.DemoInitChsFileKey, chsFile)
+	setConfig(t, constants.DemoInitWorkflowsKey, []string{"SPT Import Handler"})
+
+	setConfig(t, constants.DemoWaitEnabledKey, true)
+	setConfig(t, constants.DemoWaitTimeoutKey, 200*time.Millisecond)
+	setConfig(t, constants.DemoWaitIntervalKey, 10*time.Millisecond)
+	cmd.SetSkipVerify(t, false)
+	return filepath.Join(dest, "foo.txt")
+}
+
+// stepNames returns the names of the steps recorded in the report and
+// of those that failed.
+func stepNames(rpt *report.Report) (names []string, failed []string) {
+	for _, step := range rpt.Snapshot().Steps {
+		names = append(names, step.Name)
+		if step.Outcome == report.OutcomeFailure {
+			failed = append(failed, step.Name)
+		}
+	}
+	return names, failed
+}
+
+func TestRunUp_ExitCodes(t *testing.T) {
+	tests := []struct {
+		name     string
+		setup    func(t *testing.T, scaler *fakeScaler, staged string)
+		code     int
+		failed   string   // the step failing, if named statically
+		notStart []string // steps after the failure, which must not run
+	}{
+		{
+			name:     "config",
+			setup:    func(t *testing.T, _ *fakeScaler, _ string) { setConfig(t, constants.DemoServerKey, "") },
+			code:     2,
+			failed:   "validate-config",
+			notStart: []string{"wait-for-scaler", "count-scaler-workflows", "verify-staged-files"},
+		},
+		{
+			name: "stage",
+			setup: func(t *testing.T, _ *fakeScaler, _ string) {
+				setConfig(t, constants.DemoStageFilesKey, []stage.FilesToCopy{{
+					Source:      filepath.Join(t.TempDir(), "missing"),
+					Destination: t.TempDir(),
+				}})
+			},
+			code:     4,
+			notStart: []string{"wait-for-scaler", "count-scaler-workflows", "verify-staged-files"},
+		},
+		{
+			name:     "not ready",
+			setup:    func(_ *testing.T, scaler *fakeScaler, _ string) { scaler.healthStatus = http.StatusServiceUnavailable },
+			code:     3,
+			failed:   "wait-for-scaler",
+			notStart: []string{"count-scaler-workflows", "upload-icm-changeset", "verify-staged-files"},
+		},
+		{
+			name:     "init",
+			setup:    func(_ *testing.T, scaler *fakeScaler, _ string) { scaler.uploadStatus = http.StatusInternalServerError },
+			code:     5,
+			failed:   "upload-icm-changeset",
+			notStart: []string{"find-scaler-workflows", "deploy-scaler-workflows", "verify-staged-files"},
+		},
+		{
+			name: "verify",
+			setup: func(t *testing.T, scaler *fakeScaler, staged string) {
+				// The staged file changes while the demo is initialized.
+				scaler.onUpload = func() { assert.NoError(t, os.WriteFile(staged, []byte("changed"), 0o600)) }
+			},
+			code:   6,
+			failed: "verify-staged-files",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scaler := &fakeScaler{healthStatus: http.StatusOK, uploadStatus: http.StatusOK}
+			staged := upSetup(t, scaler)
+			tt.setup(t, scaler, staged)
+
+			rpt := report.New("demo up")
+			err := cmd.RunUp(context.Background(), rpt)
+			require.Error(t, err)
+			assert.Equal(t, tt.code, cmd.ExitCode(err))
+
+			names, failed := stepNames(rpt)
+			if tt.failed != "" {
+				assert.Contains(t, failed, tt.failed)
+			}
+			for _, name := range tt.notStart {
+				assert.NotContains(t, names, name)
+			}
+		})
+	}
+}
+
+func TestExitCode_Unannotated(t *testing.T) {
+	assert.Equal(t, 1, cmd.ExitCode(errors.New("unexpected")))
+}
+
+func TestWriteTerminationMessage(t *testing.T) {
+	scaler := &fakeScaler{healthStatus: http.StatusServiceUnavailable, uploadStatus: http.StatusOK}
+	upSetup(t, scaler)
+	err := cmd.RunUp(context.Background(), report.New("demo up"))
+	require.Error(t, err)
+
+	t.Run("given path", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "termination-log")
+		cmd.SetTerminationLog(t, path, true)
+		cmd.WriteTerminationMessage(err)
+
+		message, readErr := os.ReadFile(path)
+		require.NoError(t, readErr)
+		assert.True(t, strings.HasPrefix(string(message), "demo up failed at wait (exit code 3): "))
+		assert.Contains(t, string(message), "Scaler not ready")
+	})
+
+	t.Run("default path missing", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "termination-log")
+		cmd.SetTerminationLog(t, path, false)
+		cmd.WriteTerminationMessage(err)
+		assert.NoFileExists(t, path)
+	})
+
+	t.Run("default path exists", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "termination-log")
+		require.NoError(t, os.WriteFile(path, nil, 0o600))
+		cmd.SetTerminationLog(t, path, false)
+		cmd.WriteTerminationMessage(err)
+
+		message, readErr := os.ReadFile(path)
+		require.NoError(t, readErr)
+		assert.Contains(t, string(message), "exit code 3")
+	})
+
+	t.Run("unannotated error", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "termination-log")
+		cmd.SetTerminationLog(t, path, true)
+		cmd.WriteTerminationMessage(errors.New("unexpected"))
+
+		message, readErr := os.ReadFile(path)
+		require.NoError(t, readErr)
+		assert.Equal(t, "demo up failed (exit code 1): unexpected", string(message))
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "termination-log")
+		cmd.SetTerminationLog(t, path, true)
+		cmd.WriteTerminationMessage(errors.New(strings.Repeat("x", 5000)))
+
+		message, readErr := os.ReadFile(path)
+		require.NoError(t, readErr)
+		assert.Len(t, message, 4096)
+	})
+}
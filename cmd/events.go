@@ -0,0 +1,68 @@
+//
+// Copyright (c) 2024 Quadient Group AG
+//
+// This file is subject to the terms and conditions defined in the
+// 'LICENSE' file found in the root of this source code package.
+//
+
+package cmd
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/robertwtucker/spt-util/pkg/constants"
+	"github.com/robertwtucker/spt-util/pkg/eventbus"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// eventsCmd represents the events command.
+var eventsCmd = &cobra.Command{
+	Use:   "events",
+	Short: "Operations with the recorded events",
+	Long: `
+Inspects the events recorded in the event log (events.file) by demo commands
+	`,
+	Example: `
+# show the events of the last run
+spt-util events show
+	`,
+}
+
+//nolint:gochecknoinits // required for proper cobra initialization.
+func init() {
+	rootCmd.AddCommand(eventsCmd)
+}
+
+// openEventStore opens the configured event log to record the events of
+// a command run. It returns nil if no event log is configured or it
+// can't be opened; the run goes on without recording its events.
+func openEventStore(command string) *eventbus.FileStore {
+	path := viper.GetString(constants.EventsFileKey)
+	if path == "" {
+		return nil
+	}
+
+	var redact eventbus.Redactor
+	if logRedactor != nil {
+		redact = logRedactor.Value
+	}
+	run := newRunID()
+	store, err := eventbus.OpenFileStore(path, run, command, redact)
+	if err != nil {
+		log.Warn("events will not be recorded: ", err)
+		return nil
+	}
+	log.WithFields(log.Fields{"path": path, "run": run}).Info("recording events")
+	return store
+}
+
+// newRunID returns an ID for a command run that sorts by start time.
+func newRunID() string {
+	suffix := make([]byte, 3) //nolint:gomnd // enough to tell concurrent runs apart.
+	_, _ = rand.Read(suffix)
+	return time.Now().UTC().Format("20060102T150405Z") + "-" + hex.EncodeToString(suffix)
+}
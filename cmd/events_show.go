@@ -0,0 +1,87 @@
+//
+// Copyright (c) 2024 Quadient Group AG
+//
+// This file is subject to the terms and conditions defined in the
+// 'LICENSE' file found in the root of this source code package.
+//
+
+package cmd
+
+import (
+	"os"
+
+	"github.com/robertwtucker/spt-util/pkg/constants"
+	"github.com/robertwtucker/spt-util/pkg/eventbus"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var showCmdArgs struct {
+	Run    string
+	All    bool
+	Event  string
+	Output string
+}
+
+// showCmd represents the events show command.
+var showCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Displays the history of a run",
+	Long: `
+Displays the events recorded during a run in the event log (events.file or
+--file) for troubleshooting: when each event was published, in which order and
+with which data. Secrets in the data were redacted when the event was recorded.
+
+The last run is shown unless another one is selected with --run, or all runs
+with --all. The events can be filtered with a topic pattern: * matches any
+characters within a level of a topic (levels are separated by dots) and **
+matches any number of levels.
+	`,
+	Example: `
+# show the events of the last run
+spt-util events show --file /var/log/spt-util/events.jsonl
+
+# show the workflow events of a run as JSON lines
+spt-util events show --run 20241018T193204Z-4f2a1c --event 'init-*-workflows' -o json
+	`,
+	Run: func(cmd *cobra.Command, args []string) {
+		path := viper.GetString(constants.EventsFileKey)
+		if path == "" {
+			log.Fatal("no event log configured: set events.file or use --file")
+		}
+		file, err := os.Open(path) //nolint:gosec // path is configured by the user.
+		if err != nil {
+			log.Fatalf("error opening event log: %s", err)
+		}
+		defer func() { _ = file.Close() }()
+		records, err := eventbus.ReadRecords(file)
+		if err != nil {
+			log.Fatalf("error reading event log: %s", err)
+		}
+
+		run := showCmdArgs.Run
+		if run == "" && !showCmdArgs.All {
+			if runs := eventbus.Runs(records); len(runs) > 0 {
+				run = runs[len(runs)-1]
+			}
+		}
+		records = eventbus.FilterRecords(records, run, showCmdArgs.Event)
+		if err = eventbus.WriteRecords(os.Stdout, records, showCmdArgs.Output); err != nil {
+			log.Fatalf("error displaying events: %s", err)
+		}
+	},
+}
+
+//nolint:gochecknoinits // required for proper cobra initialization.
+func init() {
+	showCmd.Flags().String("file", "", "specify the event log (default is events.file)")
+	showCmd.Flags().StringVar(&showCmdArgs.Run, "run", "", "select a run (default is the last one)")
+	showCmd.Flags().BoolVar(&showCmdArgs.All, "all", false, "show the events of all runs")
+	showCmd.Flags().StringVar(&showCmdArgs.Event, "event", "", "show the events matching a topic pattern")
+	showCmd.Flags().StringVarP(&showCmdArgs.Output, "output", "o",
+		eventbus.FormatText, "set the output format [text|json]")
+	bindFlag(constants.EventsFileKey, showCmd.Flags().Lookup("file"))
+
+	eventsCmd.AddCommand(showCmd)
+}
@@ -0,0 +1,59 @@
+//
+// Copyright (c) 2024 Quadient Group AG
+//
+// This file is subject to the terms and conditions defined in the
+// 'LICENSE' file found in the root of this source code package.
+//
+
+package cmd
+
+import (
+	"github.com/pkg/errors"
+)
+
+// Exit codes. They are distinct so that a Kubernetes Job can tell
+// permanent failures, which retrying will not fix, from transient ones
+// (see the podFailurePolicy example of demo up).
+const (
+	exitFailure  = 1 // unexpected failure
+	exitConfig   = 2 // invalid configuration (permanent)
+	exitNotReady = 3 // Scaler not ready in time (transient)
+	exitStage    = 4 // staging files failed
+	exitInit     = 5 // initializing the demo failed
+	exitVerify   = 6 // staged files changed after staging
+)
+
+// exitError is an error terminating the application with a specific
+// exit code.
+type exitError struct {
+	code int
+	step string // the step that failed, if known
+	err  error
+}
+
+// withExitCode annotates err with the exit code and failed step.
+func withExitCode(code int, step string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &exitError{code: code, step: step, err: err}
+}
+
+// Error implements error.
+func (e *exitError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the annotated error.
+func (e *exitError) Unwrap() error {
+	return e.err
+}
+
+// exitCode returns the exit code for an error returned by a command.
+func exitCode(err error) int {
+	var exitErr *exitError
+	if errors.As(err, &exitErr) {
+		return exitErr.code
+	}
+	return exitFailure
+}
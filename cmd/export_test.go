@@ -0,0 +1,96 @@
+//
+// Copyright (c) 2024 Quadient Group AG
+//
+// This file is subject to the terms and conditions defined in the
+// 'LICENSE' file found in the root of this source code package.
+//
+
+package cmd
+
+import "testing"
+
+// RunStage exposes runStage to tests.
+var RunStage = runStage
+
+// RunInit exposes runInit to tests.
+var RunInit = runInit
+
+// RunUp exposes runUp to tests.
+var RunUp = runUp
+
+// ExitCode exposes exitCode to tests.
+var ExitCode = exitCode
+
+// WriteTerminationMessage exposes writeTerminationMessage to tests.
+var WriteTerminationMessage = writeTerminationMessage
+
+// ServeAddress exposes serveAddress to tests.
+var ServeAddress = serveAddress
+
+// SetTerminationLog sets the termination log of demo up for the
+// duration of the test, as given with --termination-log if changed is
+// true and as the default otherwise.
+func SetTerminationLog(t *testing.T, path string, changed bool) {
+	t.Helper()
+	flag := upCmd.Flags().Lookup("termination-log")
+	previous, previousChanged := upCmdArgs.TerminationLog, flag.Changed
+	upCmdArgs.TerminationLog, flag.Changed = path, changed
+	t.Cleanup(func() { upCmdArgs.TerminationLog, flag.Changed = previous, previousChanged })
+}
+
+// SetSkipVerify sets --skip-verify of demo up for the duration of the
+// test.
+func SetSkipVerify(t *testing.T, skip bool) {
+	t.Helper()
+	previous := upCmdArgs.SkipVerify
+	upCmdArgs.SkipVerify = skip
+	t.Cleanup(func() { upCmdArgs.SkipVerify = previous })
+}
+
+// ApplyProfile exposes applyProfile to tests.
+var ApplyProfile = applyProfile
+
+// SetProfile sets --profile for the duration of the test.
+func SetProfile(t *testing.T, name string) {
+	t.Helper()
+	previous, previousActive := rootCmdArgs.Profile, activeProfile
+	rootCmdArgs.Profile = name
+	t.Cleanup(func() { rootCmdArgs.Profile, activeProfile = previous, previousActive })
+}
+
+// ConfigOrigin exposes configOrigin to tests.
+var ConfigOrigin = configOrigin
+
+// FlagKeys returns the configuration keys bound to flags.
+func FlagKeys() []string {
+	keys := make([]string, 0, len(flagBindings))
+	for key := range flagBindings {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// SetConfigFlag sets the flag bound to a configuration key, as if given,
+// for the duration of the test.
+func SetConfigFlag(t *testing.T, key string, value string) {
+	t.Helper()
+	flag, ok := flagBindings[key]
+	if !ok {
+		t.Fatalf("no flag bound to %s", key)
+	}
+	previous, previousChanged := flag.Value.String(), flag.Changed
+	if err := flag.Value.Set(value); err != nil {
+		t.Fatal(err)
+	}
+	flag.Changed = true
+	t.Cleanup(func() {
+		_ = flag.Value.Set(previous)
+		flag.Changed = previousChanged
+	})
+}
+
+// FlagDefault returns the default value of the flag bound to a
+// configuration key.
+func FlagDefault(key string) string {
+	return flagBindings[key].DefValue
+}
@@ -0,0 +1,84 @@
+//
+// Copyright (c) 2024 Quadient Group AG
+//
+// This file is subject to the terms and conditions defined in the
+// 'LICENSE' file found in the root of this source code package.
+//
+
+package cmd
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/robertwtucker/spt-util/pkg/config"
+	"github.com/robertwtucker/spt-util/pkg/constants"
+	"github.com/robertwtucker/spt-util/pkg/kube"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// scalerAnnotation marks the commands that connect to the Scaler.
+const scalerAnnotation = "spt-util/scaler"
+
+//nolint:gochecknoinits // required for proper cobra initialization.
+func init() {
+	viper.SetDefault(constants.KubeDiscoverKey, true)
+	viper.SetDefault(constants.KubeTimeoutKey, 10*time.Second)
+}
+
+// usesScaler reports whether cmd connects to the Scaler.
+func usesScaler(cmd *cobra.Command) bool {
+	return cmd.Annotations[scalerAnnotation] == "true"
+}
+
+// discoverScaler fills in the Scaler URL and credentials that are not
+// configured with the settings discovered from the Helm release
+// (global.release) in the cluster namespace (global.namespace).
+// Discovery failures are logged; the settings are validated later.
+func discoverScaler() {
+	keys := []string{constants.DemoServerKey, constants.DemoUsernameKey, constants.DemoPasswordKey}
+	missing := false
+	for _, key := range keys {
+		missing = missing || viper.GetString(key) == ""
+	}
+	if !missing || !viper.GetBool(constants.KubeDiscoverKey) {
+		return
+	}
+
+	namespace := viper.GetString(constants.GlobalNamespaceKey)
+	release := viper.GetString(constants.GlobalReleaseKey)
+	logger := log.WithFields(log.Fields{"namespace": namespace, "release": release})
+	logger.Debug("discovering Scaler settings")
+
+	client, err := kube.NewClient(viper.GetString(constants.KubeConfigKey), viper.GetString(constants.KubeContextKey))
+	if err != nil {
+		logger.Warn("unable to discover Scaler settings: ", err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), viper.GetDuration(constants.KubeTimeoutKey))
+	defer cancel()
+	scaler, err := kube.DiscoverScaler(ctx, client, namespace, release)
+	if err != nil {
+		logger.Warn("unable to discover Scaler settings: ", err)
+	}
+	if scaler == nil {
+		return
+	}
+
+	discovered := map[string]string{
+		constants.DemoServerKey:   scaler.URL,
+		constants.DemoUsernameKey: scaler.Username,
+		constants.DemoPasswordKey: scaler.Password,
+	}
+	for _, key := range keys {
+		if viper.GetString(key) != "" || discovered[key] == "" {
+			continue
+		}
+		viper.Set(key, discovered[key])
+		derivedOrigins[strings.ToLower(key)] = config.OriginKube
+		logger.WithField("key", key).Info("using discovered setting")
+	}
+}
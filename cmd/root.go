@@ -11,12 +11,18 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"sort"
 	"strings"
 
+	"github.com/pkg/errors"
+	"github.com/robertwtucker/spt-util/pkg/config"
 	"github.com/robertwtucker/spt-util/pkg/constants"
+	"github.com/robertwtucker/spt-util/pkg/redact"
+	"github.com/robertwtucker/spt-util/pkg/secret"
 	"github.com/robertwtucker/spt-util/pkg/version"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 )
 
@@ -26,8 +32,20 @@ var rootCmdArgs struct {
 	LogDebug   bool
 	Release    string
 	Namespace  string
+	Profile    string
 }
 
+// activeProfile is the name of the profile overlaid onto the config
+// file settings, if any.
+var activeProfile string
+
+// logRedactor redacts secrets from log entries and recorded events.
+var logRedactor *redact.Hook
+
+// configErr records a failure to load the configuration, reported
+// before any command runs.
+var configErr error
+
 // rootCmd represents the base command when called without any subcommands.
 var rootCmd = &cobra.Command{
 	Use:   constants.AppName,
@@ -43,12 +61,56 @@ spt-util demo init -d
 # stage files in a demo environment using a custom configuration file
 spt-util demo stage -c <path-to-config.yaml>
 
+# stage, initialize and verify a demo environment in one run, e.g. in a Job
+spt-util demo up
+
+# initialize a demo environment using the settings of a profile
+spt-util demo init --profile acme-dev
+
+# wait for Scaler to accept requests before using it
+spt-util scaler wait --timeout 10m
+
+# serve the demo operations as a REST API
+spt-util serve
+
+# trace a demo initialization with an OpenTelemetry collector
+OTEL_TRACES_EXPORTER=otlp spt-util demo init
+
+# inspect the events recorded during the last demo init
+spt-util events show
+
+# check the configuration for problems
+spt-util config validate -c <path-to-config.yaml>
+
 # display application version information
 spt-util --version
 	`,
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-		initLog()
-		logrus.WithField("version", version.GetVersion()).Info("initialized")
+		if err := initLog(); err != nil {
+			cmd.SilenceUsage = true
+			return err
+		}
+		if configErr != nil {
+			cmd.SilenceUsage = true
+			return withExitCode(exitConfig, "read-config", configErr)
+		}
+		logrus.WithFields(logrus.Fields{
+			"version": version.GetVersion(),
+			"profile": activeProfile,
+		}).Info("initialized")
+		if err := startTracing(cmd); err != nil {
+			cmd.SilenceUsage = true
+			return withExitCode(exitConfig, "init-tracing", err)
+		}
+		if usesScaler(cmd) {
+			discoverScaler()
+		}
+		if isDemoCommand(cmd) || usesScaler(cmd) {
+			if err := validateConfig(); err != nil {
+				cmd.SilenceUsage = true
+				return withExitCode(exitConfig, "validate-config", err)
+			}
+		}
 		return nil
 	},
 }
@@ -56,9 +118,13 @@ spt-util --version
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
-	err := rootCmd.Execute()
+	cmd, err := rootCmd.ExecuteC()
+	endTracing(err)
 	if err != nil {
-		os.Exit(1)
+		if cmd == upCmd {
+			writeTerminationMessage(err)
+		}
+		os.Exit(exitCode(err))
 	}
 }
 
@@ -76,6 +142,15 @@ func init() {
 		"inspire", "set the inspire release name used")
 	rootCmd.PersistentFlags().StringVarP(&rootCmdArgs.Namespace, "namespace", "n",
 		"default", "set the cluster namespace to target")
+	rootCmd.PersistentFlags().StringVar(&rootCmdArgs.Profile, "profile",
+		"", "select a profile from the config file (default is $"+constants.ProfileEnv+")")
+
+	// Flags override the config file (and profile) only when given.
+	bindFlag(constants.GlobalReleaseKey, rootCmd.PersistentFlags().Lookup("release"))
+	bindFlag(constants.GlobalNamespaceKey, rootCmd.PersistentFlags().Lookup("namespace"))
+
+	// Commands are traced with the exporter of the OpenTelemetry variable
+	bindEnv(constants.TracingExporterKey, constants.TracingEnv)
 
 	rootCmd.Version = version.GetVersion()             // Enable the version option
 	rootCmd.CompletionOptions.DisableDefaultCmd = true // Hide the completion options
@@ -99,16 +174,124 @@ func initConfig() {
 		_, _ = fmt.Fprintln(os.Stderr, "Using config file:", viper.ConfigFileUsed())
 	}
 
-	// Override config file settings with command line arguments, if present.
-	if rootCmdArgs.Release != "" {
-		viper.Set(constants.GlobalReleaseKey, rootCmdArgs.Release)
+	// Overlay the selected profile onto the config file settings.
+	if configErr = applyProfile(); configErr == nil && activeProfile != "" {
+		_, _ = fmt.Fprintln(os.Stderr, "Using profile:", activeProfile)
+	}
+	if configErr == nil {
+		configErr = resolveSecrets()
+	}
+}
+
+// applyProfile merges the profile selected by --profile or SPT_PROFILE
+// into the config file settings. Environment variables and flags still
+// take precedence over profile values.
+func applyProfile() error {
+	name := rootCmdArgs.Profile
+	if name == "" {
+		name = os.Getenv(constants.ProfileEnv)
+	}
+	if name == "" {
+		return nil
+	}
+
+	profiles := viper.GetStringMap(constants.ProfilesKey)
+	profile, ok := profiles[strings.ToLower(name)].(map[string]interface{})
+	if !ok {
+		available := make([]string, 0, len(profiles))
+		for key := range profiles {
+			available = append(available, key)
+		}
+		sort.Strings(available)
+		return errors.Errorf("unknown profile %q (available: %s)", name, strings.Join(available, ", "))
 	}
-	if rootCmdArgs.Namespace != "" {
-		viper.Set(constants.GlobalNamespaceKey, rootCmdArgs.Namespace)
+	if err := viper.MergeConfigMap(profile); err != nil {
+		return errors.Wrapf(err, "error applying profile %q", name)
 	}
+
+	activeProfile = strings.ToLower(name)
+	return nil
 }
 
-func initLog() {
+// secretDirKeys maps the credentials read from a mounted secret
+// directory to their file names.
+var secretDirKeys = map[string]string{
+	constants.DemoUsernameKey: "username",
+	constants.DemoPasswordKey: "password",
+}
+
+// flagBindings maps the configuration keys bound to flags to those
+// flags, so that config view can tell which values were given as flags.
+var flagBindings = map[string]*pflag.Flag{}
+
+// bindFlag binds a configuration key to a flag, which overrides the
+// config file (and profile) only when given.
+func bindFlag(key string, flag *pflag.Flag) {
+	flagBindings[strings.ToLower(key)] = flag
+	_ = viper.BindPFlag(key, flag)
+}
+
+// envBindings maps the configuration keys bound to environment
+// variables other than the automatic ones to those variables.
+var envBindings = map[string]string{}
+
+// bindEnv binds a configuration key to an environment variable.
+func bindEnv(key string, env string) {
+	envBindings[strings.ToLower(key)] = env
+	_ = viper.BindEnv(key, env)
+}
+
+// derivedOrigins records the origin of settings that were not read
+// from the config file, the environment or flags, but derived from
+// other sources such as the secret directory or the Kubernetes cluster.
+var derivedOrigins = map[string]string{}
+
+// resolveSecrets replaces secret references (file://, env:) in the
+// credential settings with the secrets they refer to. Credentials that
+// are still unset are read from the mounted Kubernetes secret directory,
+// if one is configured.
+func resolveSecrets() error {
+	for _, key := range viper.AllKeys() {
+		if strings.HasPrefix(key, constants.ProfilesKey+".") {
+			continue
+		}
+		if _, credential := secretDirKeys[key]; !credential && !config.IsSecret(key) {
+			continue
+		}
+		value, ok := viper.Get(key).(string)
+		if !ok || !secret.IsReference(value) {
+			continue
+		}
+		resolved, err := secret.Resolve(value)
+		if err != nil {
+			return errors.Wrapf(err, "error resolving secret reference for %s", key)
+		}
+		viper.Set(key, resolved)
+	}
+
+	dir := viper.GetString(constants.DemoSecretDirKey)
+	if dir == "" {
+		return nil
+	}
+	for key, name := range secretDirKeys {
+		if viper.GetString(key) != "" {
+			continue
+		}
+		value, err := secret.FromDir(dir, name)
+		if err != nil {
+			return errors.Wrapf(err, "error reading %s from secret directory", key)
+		}
+		if value != "" {
+			viper.Set(key, value)
+			derivedOrigins[key] = config.OriginSecretDir
+		}
+	}
+	return nil
+}
+
+// initLog configures the log format and level and installs the hook
+// redacting sensitive values from log entries.
+func initLog() error {
 	if strings.ToLower(rootCmdArgs.LogFormat) == "json" {
 		logrus.SetFormatter(&logrus.JSONFormatter{})
 	}
@@ -117,6 +300,21 @@ func initLog() {
 	} else {
 		logrus.SetLevel(logrus.InfoLevel)
 	}
-	log.SetOutput(logrus.New().Writer())
+
+	hook, err := redact.NewHook(
+		viper.GetStringSlice(constants.LogRedactFieldsKey),
+		viper.GetStringSlice(constants.LogRedactPatternsKey),
+	)
+	if err != nil {
+		return err
+	}
+	logrus.StandardLogger().ReplaceHooks(logrus.LevelHooks{})
+	logrus.AddHook(hook)
+	logRedactor = hook
+
+	logger := logrus.New()
+	logger.AddHook(hook)
+	log.SetOutput(logger.Writer())
 	log.SetFlags(0)
+	return nil
 }
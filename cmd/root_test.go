@@ -8,11 +8,127 @@
 package cmd_test
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/robertwtucker/spt-util/cmd"
+	"github.com/robertwtucker/spt-util/pkg/constants"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestRoot(_ *testing.T) {
 	cmd.Execute()
 }
+
+const profileConfig = `
+global:
+  release: "inspire"
+  namespace: "default"
+demo:
+  server: "https://scaler.example.com"
+profiles:
+  acme-dev:
+    global:
+      release: "acme"
+      namespace: "acme-dev"
+    demo:
+      server: "https://scaler.acme-dev.example.com"
+  acme-prod:
+    global:
+      namespace: "acme-prod"
+`
+
+// readConfig reads the config file content for the duration of the
+// test.
+func readConfig(t *testing.T, content string) {
+	t.Helper()
+	viper.SetConfigType("yaml")
+	require.NoError(t, viper.ReadConfig(strings.NewReader(content)))
+	t.Cleanup(func() { _ = viper.ReadConfig(strings.NewReader("")) })
+}
+
+func TestApplyProfile(t *testing.T) {
+	tests := []struct {
+		name      string
+		flag      string
+		env       string
+		release   string
+		namespace string
+		server    string
+	}{
+		{
+			name:      "none",
+			release:   "inspire",
+			namespace: "default",
+			server:    "https://scaler.example.com",
+		},
+		{
+			name:      "flag",
+			flag:      "acme-dev",
+			release:   "acme",
+			namespace: "acme-dev",
+			server:    "https://scaler.acme-dev.example.com",
+		},
+		{
+			name:      "environment",
+			env:       "acme-dev",
+			release:   "acme",
+			namespace: "acme-dev",
+			server:    "https://scaler.acme-dev.example.com",
+		},
+		{
+			name:      "flag over environment",
+			flag:      "acme-prod",
+			env:       "acme-dev",
+			release:   "inspire",
+			namespace: "acme-prod",
+			server:    "https://scaler.example.com",
+		},
+		{
+			name:      "case insensitive",
+			flag:      "ACME-Dev",
+			release:   "acme",
+			namespace: "acme-dev",
+			server:    "https://scaler.acme-dev.example.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			readConfig(t, profileConfig)
+			cmd.SetProfile(t, tt.flag)
+			t.Setenv(constants.ProfileEnv, tt.env)
+
+			require.NoError(t, cmd.ApplyProfile())
+			assert.Equal(t, tt.release, viper.GetString(constants.GlobalReleaseKey))
+			assert.Equal(t, tt.namespace, viper.GetString(constants.GlobalNamespaceKey))
+			assert.Equal(t, tt.server, viper.GetString(constants.DemoServerKey))
+		})
+	}
+}
+
+func TestApplyProfile_Overrides(t *testing.T) {
+	readConfig(t, profileConfig)
+	cmd.SetProfile(t, "acme-dev")
+	cmd.SetConfigFlag(t, constants.GlobalNamespaceKey, "flag-ns")
+	t.Setenv(constants.DemoServerEnv, "https://scaler.env.example.com")
+
+	require.NoError(t, cmd.ApplyProfile())
+	// The profile overrides the config file, flags and the environment
+	// override the profile.
+	assert.Equal(t, "acme", viper.GetString(constants.GlobalReleaseKey))
+	assert.Equal(t, "flag-ns", viper.GetString(constants.GlobalNamespaceKey))
+	assert.Equal(t, "https://scaler.env.example.com", viper.GetString(constants.DemoServerKey))
+}
+
+func TestApplyProfile_Unknown(t *testing.T) {
+	readConfig(t, profileConfig)
+	cmd.SetProfile(t, "acme-test")
+
+	err := cmd.ApplyProfile()
+	assert.EqualError(t, err, `unknown profile "acme-test" (available: acme-dev, acme-prod)`)
+	// The config file settings are left as they are.
+	assert.Equal(t, "default", viper.GetString(constants.GlobalNamespaceKey))
+}
@@ -0,0 +1,30 @@
+//
+// Copyright (c) 2024 Quadient Group AG
+//
+// This file is subject to the terms and conditions defined in the
+// 'LICENSE' file found in the root of this source code package.
+//
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// scalerCmd represents the scaler command.
+var scalerCmd = &cobra.Command{
+	Use:   "scaler",
+	Short: "Operations with the Scaler instance",
+	Long: `
+Performs operations against the Scaler instance of a demo environment
+	`,
+	Example: `
+# wait up to 10 minutes for Scaler to accept requests
+spt-util scaler wait --timeout 10m
+	`,
+}
+
+//nolint:gochecknoinits // required for proper cobra initialization.
+func init() {
+	rootCmd.AddCommand(scalerCmd)
+}
@@ -0,0 +1,101 @@
+//
+// Copyright (c) 2024 Quadient Group AG
+//
+// This file is subject to the terms and conditions defined in the
+// 'LICENSE' file found in the root of this source code package.
+//
+
+package cmd
+
+import (
+	"context"
+	"time"
+
+	"github.com/robertwtucker/spt-util/pkg/constants"
+	"github.com/robertwtucker/spt-util/pkg/report"
+	"github.com/robertwtucker/spt-util/pkg/scaler"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// waitCmd represents the scaler wait command.
+var waitCmd = &cobra.Command{
+	Use:         "wait",
+	Short:       "Waits for Scaler to be ready",
+	Annotations: map[string]string{scalerAnnotation: "true"},
+	Long: `
+Waits for Scaler and ICM to be ready by polling the Scaler health and version
+endpoints, listing the workflows and reading the ICM environments through
+Scaler with the configured credentials until all succeed or the timeout
+expires. The last error is reported if Scaler does not become ready.
+
+The probe paths can be changed with demo.wait.healthPath, demo.wait.versionPath,
+demo.wait.authPath and demo.wait.icmPath; an empty path disables the probe. The
+health, version and ICM probes are skipped if Scaler does not provide their
+endpoint (404 or 405 response).
+	`,
+	Example: `
+# wait up to 10 minutes, trying every 10 seconds
+spt-util scaler wait --timeout 10m --interval 10s
+	`,
+	Run: func(cmd *cobra.Command, args []string) {
+		log.Info("waiting for Scaler")
+		if _, err := waitForScaler(cmd.Context()); err != nil {
+			log.Fatalf("error waiting for Scaler: %s", err)
+		}
+	},
+}
+
+//nolint:gochecknoinits // required for proper cobra initialization.
+func init() {
+	//nolint:gomnd // default time limit.
+	waitCmd.Flags().Duration("timeout", 5*time.Minute, "set the time limit for Scaler to become ready")
+	//nolint:gomnd // default pause between attempts.
+	waitCmd.Flags().Duration("interval", 5*time.Second, "set the pause between attempts")
+	bindFlag(constants.DemoWaitTimeoutKey, waitCmd.Flags().Lookup("timeout"))
+	bindFlag(constants.DemoWaitIntervalKey, waitCmd.Flags().Lookup("interval"))
+	viper.SetDefault(constants.DemoWaitEnabledKey, true)
+	viper.SetDefault(constants.DemoWaitHealthKey, scaler.DefaultProbes[0].Path)
+	viper.SetDefault(constants.DemoWaitVersionKey, scaler.DefaultProbes[1].Path)
+	viper.SetDefault(constants.DemoWaitAuthKey, scaler.DefaultProbes[2].Path)
+	viper.SetDefault(constants.DemoWaitICMKey, scaler.DefaultProbes[3].Path)
+
+	scalerCmd.AddCommand(waitCmd)
+}
+
+// runWait waits for Scaler, recording the wait in the run report.
+func runWait(ctx context.Context, rpt *report.Report) error {
+	step := rpt.StartStep("wait-for-scaler")
+	_, err := waitForScaler(ctx)
+	step.Finish(err)
+	return err
+}
+
+// waitForScaler polls the configured Scaler instance until it is ready
+// or the configured timeout expires and returns the number of attempts.
+func waitForScaler(ctx context.Context) (int, error) {
+	waiter := &scaler.Waiter{
+		Host:       viper.GetString(constants.DemoServerKey),
+		AuthHeader: scalerAuthHeader(),
+		Interval:   viper.GetDuration(constants.DemoWaitIntervalKey),
+		Client:     newScalerClient(),
+	}
+	keys := []string{
+		constants.DemoWaitHealthKey, constants.DemoWaitVersionKey, constants.DemoWaitAuthKey, constants.DemoWaitICMKey,
+	}
+	for i, key := range keys {
+		probe := scaler.DefaultProbes[i]
+		if probe.Path = viper.GetString(key); probe.Path != "" {
+			waiter.Probes = append(waiter.Probes, probe)
+		}
+	}
+
+	timeout := viper.GetDuration(constants.DemoWaitTimeoutKey)
+	log.WithContext(ctx).WithFields(log.Fields{
+		"url":      waiter.Host,
+		"timeout":  timeout,
+		"interval": waiter.Interval,
+	}).Debug("waiting for Scaler")
+	return waiter.Wait(ctx, timeout)
+}
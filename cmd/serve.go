@@ -0,0 +1,173 @@
+//
+// Copyright (c) 2024 Quadient Group AG
+//
+// This file is subject to the terms and conditions defined in the
+// 'LICENSE' file found in the root of this source code package.
+//
+
+package cmd
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/robertwtucker/spt-util/pkg/constants"
+	"github.com/robertwtucker/spt-util/pkg/report"
+	"github.com/robertwtucker/spt-util/pkg/server"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// serveCmd represents the serve command.
+var serveCmd = &cobra.Command{
+	Use:         "serve",
+	Short:       "Serves demo operations as a REST API",
+	Annotations: map[string]string{scalerAnnotation: "true"},
+	Long: `
+Serves the demo operations as a REST API so that they can be triggered remotely,
+e.g. from a portal, instead of running commands in the pod. Operations run as
+asynchronous jobs, one at a time, in the order submitted:
+
+  init   initializes the demo (waiting for Scaler first, see demo init)
+  stage  stages the demo files (see demo stage)
+  reset  removes the staged demo files (see demo stage clean)
+  up     stages, initializes and verifies (see demo up)
+
+Endpoints:
+
+  GET  /healthz                       liveness
+  GET  /metrics                       Prometheus metrics
+  GET  /api/v1/operations             operation names
+  POST /api/v1/operations/{name}      submit a job, returns it (202 Accepted)
+  GET  /api/v1/jobs                   jobs, most recent first
+  GET  /api/v1/jobs/{id}              job status and steps
+  GET  /api/v1/jobs/{id}/events       server-sent events of the job: log
+                                      entries, step progress and status
+
+The event stream replays the events of the job and ends once it has finished.
+If serve.token (or SPT_SERVE_TOKEN) is set, API requests must present it as a
+bearer token; /healthz and /metrics are not protected. Without a token the API
+is unauthenticated, so it listens on 127.0.0.1:8080 by default and refuses to
+listen on an address other than a loopback one.
+	`,
+	Example: `
+# serve the API on port 9000 of all interfaces
+SPT_SERVE_TOKEN=<token> spt-util serve --address :9000
+
+# initialize the demo and follow its progress
+curl -X POST http://localhost:8080/api/v1/operations/init
+curl -N http://localhost:8080/api/v1/jobs/<id>/events
+	`,
+	Run: func(cmd *cobra.Command, args []string) {
+		token := viper.GetString(constants.ServeTokenKey)
+		address, err := serveAddress(viper.GetString(constants.ServeAddressKey), token)
+		if err != nil {
+			log.Fatalf("error serving API: %s", err)
+		}
+		if token == "" {
+			log.WithField("address", address).Warn("serving API without a token: requests are not authenticated")
+		}
+
+		srv := server.New(map[string]server.Operation{
+			"init":  serveInit,
+			"stage": serveStage,
+			"reset": serveReset,
+			"up":    runUp,
+		}, token)
+		log.AddHook(srv.LogHook())
+
+		httpServer := &http.Server{
+			Addr:              address,
+			Handler:           srv,
+			ReadHeaderTimeout: 10 * time.Second, //nolint:gomnd // generous for API clients.
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		go func() {
+			<-ctx.Done()
+			log.Info("shutting down")
+			// Event streams end with their job; don't wait for them.
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second) //nolint:gomnd // grace period.
+			defer cancel()
+			_ = httpServer.Shutdown(shutdownCtx)
+		}()
+
+		log.WithField("address", httpServer.Addr).Info("serving API")
+		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("error serving API: %s", err)
+		}
+	},
+}
+
+//nolint:gochecknoinits // required for proper cobra initialization.
+func init() {
+	serveCmd.Flags().String("address", "",
+		"set the address to listen on (default "+defaultServeAddress+", or "+loopbackServeAddress+" without a token)")
+	bindFlag(constants.ServeAddressKey, serveCmd.Flags().Lookup("address"))
+	// Get the API token from environment, not command line
+	bindEnv(constants.ServeTokenKey, constants.ServeTokenEnv)
+
+	rootCmd.AddCommand(serveCmd)
+}
+
+// Default listen addresses of the API, with and without a token.
+const (
+	defaultServeAddress  = ":8080"
+	loopbackServeAddress = "127.0.0.1:8080"
+)
+
+// serveAddress returns the address to serve the API on. Without a token
+// the API is unauthenticated, so it defaults to the loopback interface
+// and may not listen on any other.
+func serveAddress(address string, token string) (string, error) {
+	if token != "" {
+		if address == "" {
+			return defaultServeAddress, nil
+		}
+		return address, nil
+	}
+	if address == "" {
+		return loopbackServeAddress, nil
+	}
+
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid address %s", address)
+	}
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return "", errors.Errorf("refusing to serve on non-loopback address %s without a token: set %s",
+			address, constants.ServeTokenEnv)
+	}
+	return address, nil
+}
+
+// serveInit initializes the demo as demo init does.
+func serveInit(ctx context.Context, rpt *report.Report) error {
+	if viper.GetBool(constants.DemoWaitEnabledKey) {
+		if err := runWait(ctx, rpt); err != nil {
+			return err
+		}
+	}
+	return runInit(ctx, rpt)
+}
+
+// serveStage stages the demo files as demo stage does.
+func serveStage(ctx context.Context, rpt *report.Report) error {
+	files, err := stageFiles(rpt)
+	if err != nil {
+		return err
+	}
+	return runStage(ctx, rpt, files)
+}
+
+// serveReset removes the staged demo files as demo stage clean does.
+func serveReset(ctx context.Context, rpt *report.Report) error {
+	return runStageClean(ctx, rpt)
+}
@@ -0,0 +1,48 @@
+//
+// Copyright (c) 2024 Quadient Group AG
+//
+// This file is subject to the terms and conditions defined in the
+// 'LICENSE' file found in the root of this source code package.
+//
+
+package cmd_test
+
+import (
+	"testing"
+
+	"github.com/robertwtucker/spt-util/cmd"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServeAddress(t *testing.T) {
+	tests := []struct {
+		name     string
+		address  string
+		token    string
+		expected string
+		err      bool
+	}{
+		{name: "default with token", token: "secret", expected: ":8080"},
+		{name: "default without token", expected: "127.0.0.1:8080"},
+		{name: "any address with token", address: ":9000", token: "secret", expected: ":9000"},
+		{name: "loopback without token", address: "127.0.0.1:9000", expected: "127.0.0.1:9000"},
+		{name: "ipv6 loopback without token", address: "[::1]:9000", expected: "[::1]:9000"},
+		{name: "localhost without token", address: "localhost:9000", expected: "localhost:9000"},
+		{name: "all interfaces without token", address: ":9000", err: true},
+		{name: "interface without token", address: "10.0.0.1:9000", err: true},
+		{name: "host name without token", address: "example.com:9000", err: true},
+		{name: "invalid address", address: "9000", err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			address, err := cmd.ServeAddress(tt.address, tt.token)
+			if tt.err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, address)
+		})
+	}
+}
@@ -0,0 +1,82 @@
+//
+// Copyright (c) 2024 Quadient Group AG
+//
+// This file is subject to the terms and conditions defined in the
+// 'LICENSE' file found in the root of this source code package.
+//
+
+package cmd
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/robertwtucker/spt-util/pkg/constants"
+	"github.com/robertwtucker/spt-util/pkg/tracing"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tracing state of the running command.
+var (
+	commandSpan     trace.Span
+	shutdownTracing tracing.Shutdown
+	endTracingOnce  sync.Once
+)
+
+// startTracing sets up the configured span exporter and starts the span
+// of the command, passing it to the command's run function through its
+// context. Spans are flushed by endTracing, which also runs when the
+// command exits through log.Fatal.
+func startTracing(cmd *cobra.Command) error {
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	shutdown, err := tracing.Setup(ctx,
+		viper.GetString(constants.TracingExporterKey),
+		viper.GetString(constants.TracingEndpointKey),
+		// Keep spans apart from the output of commands, e.g. events show.
+		os.Stderr,
+	)
+	if err != nil {
+		return err
+	}
+	shutdownTracing = shutdown
+	log.RegisterExitHandler(func() { endTracing(errors.New("command failed")) })
+
+	ctx, commandSpan = tracing.Tracer().Start(ctx, cmd.CommandPath(),
+		trace.WithAttributes(
+			attribute.String("namespace", viper.GetString(constants.GlobalNamespaceKey)),
+			attribute.String("release", viper.GetString(constants.GlobalReleaseKey)),
+		),
+	)
+	cmd.SetContext(ctx)
+	return nil
+}
+
+// endTracing ends the span of the command, marking it failed if err is
+// not nil, and flushes the spans recorded.
+func endTracing(err error) {
+	endTracingOnce.Do(func() {
+		if commandSpan != nil {
+			tracing.Fail(commandSpan, err)
+			commandSpan.End()
+		}
+		if shutdownTracing == nil {
+			return
+		}
+		//nolint:gomnd // grace period for exporting the spans.
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			log.Warn("error exporting traces: ", err)
+		}
+	})
+}
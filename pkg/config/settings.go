@@ -0,0 +1,148 @@
+//
+// Copyright (c) 2024 Quadient Group AG
+//
+// This file is subject to the terms and conditions defined in the
+// 'LICENSE' file found in the root of this source code package.
+//
+
+package config
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Redacted replaces the values of secret settings.
+const Redacted = "[REDACTED]"
+
+// secretNames are key names that always hold secrets.
+var secretNames = []string{"pass", "auth", "authorization", "authheader"}
+
+// secretParts are key name fragments that indicate a secret.
+var secretParts = []string{"password", "passwd", "secret", "token", "apikey", "api_key", "credential", "privatekey"}
+
+// locationSuffixes mark key names that hold the location of a secret,
+// e.g. secretDir or tokenFile, rather than the secret itself.
+var locationSuffixes = []string{"dir", "file", "path"}
+
+// IsSecret reports whether the (dotted) key names a secret value.
+func IsSecret(key string) bool {
+	name := strings.ToLower(key[strings.LastIndex(key, ".")+1:])
+	for _, suffix := range locationSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return false
+		}
+	}
+	for _, secret := range secretNames {
+		if name == secret {
+			return true
+		}
+	}
+	for _, part := range secretParts {
+		if strings.Contains(name, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// Canonicalize converts settings, as returned by viper.AllSettings, to
+// plain JSON values and restores the spelling of the keys described by
+// the schema, which viper lower-cases. Durations become strings such
+// as "5s".
+func Canonicalize(settings map[string]interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(plain(settings))
+	if err != nil {
+		return nil, errors.Wrap(err, "error encoding configuration")
+	}
+	var instance map[string]interface{}
+	if err = json.Unmarshal(data, &instance); err != nil {
+		return nil, errors.Wrap(err, "error decoding configuration")
+	}
+
+	var doc map[string]interface{}
+	if err = json.Unmarshal(Schema, &doc); err != nil {
+		return nil, errors.Wrap(err, "error parsing configuration schema")
+	}
+	result, _ := canonicalize(instance, doc, doc).(map[string]interface{})
+	return result, nil
+}
+
+// plain converts values without a JSON representation matching their
+// configuration syntax.
+func plain(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			result[key] = plain(item)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = plain(item)
+		}
+		return result
+	case time.Duration:
+		return v.String()
+	default:
+		return v
+	}
+}
+
+// Redact returns a copy of settings with the values of secret keys
+// replaced.
+func Redact(settings map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(settings))
+	for key, value := range settings {
+		result[key] = redactValue(key, value)
+	}
+	return result
+}
+
+// redactValue redacts a single setting value.
+func redactValue(key string, value interface{}) interface{} {
+	if IsSecret(key) {
+		if s, ok := value.(string); ok && s == "" {
+			return s
+		}
+		return Redacted
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return Redact(v)
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = redactValue("", item)
+		}
+		return result
+	default:
+		return v
+	}
+}
+
+// Keys returns the dotted key paths of the settings' values, sorted.
+// Lists are values; the keys of their items are not included.
+func Keys(settings map[string]interface{}) []string {
+	var keys []string
+	var walk func(prefix string, m map[string]interface{})
+	walk = func(prefix string, m map[string]interface{}) {
+		for key, value := range m {
+			path := joinKey(prefix, key)
+			if child, ok := value.(map[string]interface{}); ok && len(child) > 0 {
+				walk(path, child)
+				continue
+			}
+			keys = append(keys, path)
+		}
+	}
+	walk("", settings)
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,190 @@
+//
+// Copyright (c) 2024 Quadient Group AG
+//
+// This file is subject to the terms and conditions defined in the
+// 'LICENSE' file found in the root of this source code package.
+//
+
+// Package config validates and renders the application configuration.
+package config
+
+import (
+	"bytes"
+	_ "embed" // required for the embedded schema.
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// Schema is the JSON Schema describing the configuration file.
+//
+//go:embed schema.json
+var Schema []byte
+
+// schemaURL identifies the embedded schema when compiling it.
+const schemaURL = "spt-util.schema.json"
+
+// Problem describes a configuration value that does not match the
+// schema.
+type Problem struct {
+	Path    string // dotted key path, e.g. demo.stage.files[0].src
+	Message string
+}
+
+// String implements fmt.Stringer.
+func (p Problem) String() string {
+	if p.Path == "" {
+		return p.Message
+	}
+	return p.Path + ": " + p.Message
+}
+
+// Validate checks the configuration settings, as returned by
+// viper.AllSettings, against the schema and returns every problem
+// found, ordered by key path.
+func Validate(settings map[string]interface{}) ([]Problem, error) {
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource(schemaURL, bytes.NewReader(Schema)); err != nil {
+		return nil, errors.Wrap(err, "error loading configuration schema")
+	}
+	schema, err := compiler.Compile(schemaURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "error compiling configuration schema")
+	}
+
+	instance, err := Canonicalize(settings)
+	if err != nil {
+		return nil, err
+	}
+
+	err = schema.Validate(instance)
+	if err == nil {
+		return nil, nil
+	}
+	var validationErr *jsonschema.ValidationError
+	if !errors.As(err, &validationErr) {
+		return nil, errors.Wrap(err, "error validating configuration")
+	}
+
+	problems := collect(validationErr, nil)
+	sort.SliceStable(problems, func(i, j int) bool { return problems[i].Path < problems[j].Path })
+	return problems, nil
+}
+
+// canonicalize renames the object keys of value that match a property
+// of the schema node case-insensitively to the property's name.
+func canonicalize(value interface{}, node map[string]interface{}, root map[string]interface{}) interface{} {
+	node = resolve(node, root)
+	switch v := value.(type) {
+	case map[string]interface{}:
+		properties, _ := node["properties"].(map[string]interface{})
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			for name, property := range properties {
+				if strings.EqualFold(key, name) {
+					key = name
+					if child, ok := property.(map[string]interface{}); ok {
+						item = canonicalize(item, child, root)
+					}
+					break
+				}
+			}
+			result[key] = item
+		}
+		return result
+	case []interface{}:
+		items, ok := node["items"].(map[string]interface{})
+		if !ok {
+			return v
+		}
+		for i := range v {
+			v[i] = canonicalize(v[i], items, root)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// resolve follows a local "$ref" of a schema node.
+func resolve(node map[string]interface{}, root map[string]interface{}) map[string]interface{} {
+	ref, ok := node["$ref"].(string)
+	if !ok || !strings.HasPrefix(ref, "#/") {
+		return node
+	}
+	var target interface{} = root
+	for _, name := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		m, isMap := target.(map[string]interface{})
+		if !isMap {
+			return node
+		}
+		target = m[name]
+	}
+	if m, isMap := target.(map[string]interface{}); isMap {
+		return resolve(m, root)
+	}
+	return node
+}
+
+// collect returns the innermost causes of a validation error as
+// problems. Missing properties are reported at their own key path.
+func collect(err *jsonschema.ValidationError, problems []Problem) []Problem {
+	if len(err.Causes) > 0 {
+		for _, cause := range err.Causes {
+			problems = collect(cause, problems)
+		}
+		return problems
+	}
+
+	path := keyPath(err.InstanceLocation)
+	if names, ok := missingProperties(err.Message); ok {
+		for _, name := range names {
+			problems = append(problems, Problem{Path: joinKey(path, name), Message: "missing required value"})
+		}
+		return problems
+	}
+	return append(problems, Problem{Path: path, Message: err.Message})
+}
+
+// missingProperties extracts the property names from a "missing
+// properties" message.
+func missingProperties(message string) ([]string, bool) {
+	const prefix = "missing properties: "
+	if !strings.HasPrefix(message, prefix) {
+		return nil, false
+	}
+	var names []string
+	for _, name := range strings.Split(strings.TrimPrefix(message, prefix), ",") {
+		names = append(names, strings.Trim(strings.TrimSpace(name), "'"))
+	}
+	return names, true
+}
+
+// keyPath converts a JSON pointer into a dotted key path.
+func keyPath(pointer string) string {
+	var path string
+	for _, token := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		if token == "" {
+			continue
+		}
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		if _, err := strconv.Atoi(token); err == nil {
+			path += fmt.Sprintf("[%s]", token)
+			continue
+		}
+		path = joinKey(path, token)
+	}
+	return path
+}
+
+// joinKey appends a key to a dotted key path.
+func joinKey(path string, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,118 @@
+//
+// Copyright (c) 2024 Quadient Group AG
+//
+// This file is subject to the terms and conditions defined in the
+// 'LICENSE' file found in the root of this source code package.
+//
+
+package config_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/robertwtucker/spt-util/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// settings mirrors the lower-cased map returned by viper.AllSettings.
+func settings() map[string]interface{} {
+	return map[string]interface{}{
+		"global": map[string]interface{}{
+			"release":   "inspire",
+			"namespace": "default",
+		},
+		"demo": map[string]interface{}{
+			"server": "https://scaler.example.com",
+			"init": map[string]interface{}{
+				"envfile":   "/deployment/env.json",
+				"chsfile":   "/deployment/import.chs",
+				"workflows": []interface{}{"SPT Content Import"},
+			},
+			"stage": map[string]interface{}{
+				"workers":          1,
+				"progressinterval": 5 * time.Second,
+				"files": []interface{}{
+					map[string]interface{}{
+						"src":           "/deployment/base.zip",
+						"dest":          "/opt/base.zip",
+						"skipunchanged": true,
+						"compare":       "mtime",
+					},
+				},
+			},
+		},
+		"custom": map[string]interface{}{"anything": "goes"},
+	}
+}
+
+func TestValidate(t *testing.T) {
+	problems, err := config.Validate(settings())
+	require.NoError(t, err)
+	assert.Empty(t, problems)
+}
+
+func TestValidate_StageDefaultsOnly(t *testing.T) {
+	s := settings()
+	s["demo"].(map[string]interface{})["stage"] = map[string]interface{}{
+		"workers":          1,
+		"progressinterval": 5 * time.Second,
+	}
+
+	problems, err := config.Validate(s)
+	require.NoError(t, err)
+	assert.Empty(t, problems)
+}
+
+func TestValidate_CaseInsensitiveOptions(t *testing.T) {
+	s := settings()
+	stage := s["demo"].(map[string]interface{})["stage"].(map[string]interface{})
+	stage["files"] = []interface{}{
+		map[string]interface{}{
+			"src":       "/deployment/base.zip",
+			"dest":      "/opt/base",
+			"action":    "Extract",
+			"format":    "TAR.GZ",
+			"compare":   "Hash",
+			"overwrite": "Always",
+			"symlinks":  "Deep",
+		},
+	}
+	s["tracing"] = map[string]interface{}{"exporter": "OTLP"}
+
+	problems, err := config.Validate(s)
+	require.NoError(t, err)
+	assert.Empty(t, problems)
+}
+
+func TestValidate_Problems(t *testing.T) {
+	s := settings()
+	demo := s["demo"].(map[string]interface{})
+	init := demo["init"].(map[string]interface{})
+	delete(init, "envfile")
+	init["workflows"] = []interface{}{}
+	demo["server"] = "scaler.example.com"
+	stage := demo["stage"].(map[string]interface{})
+	stage["files"] = []interface{}{
+		map[string]interface{}{"src": "/a", "dest": "/b", "compare": "crc"},
+		map[string]interface{}{"src": "/a", "skipunchnaged": true},
+	}
+
+	problems, err := config.Validate(s)
+	require.NoError(t, err)
+	paths := make([]string, 0, len(problems))
+	for _, problem := range problems {
+		paths = append(paths, problem.Path)
+	}
+	assert.Equal(t, []string{
+		"demo.init.envFile",
+		"demo.init.workflows",
+		"demo.server",
+		"demo.stage.files[0].compare",
+		"demo.stage.files[1]",
+		"demo.stage.files[1].dest",
+	}, paths)
+	assert.Equal(t, "demo.init.envFile: missing required value", problems[0].String())
+	assert.Contains(t, problems[4].Message, "skipunchnaged")
+}
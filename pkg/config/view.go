@@ -0,0 +1,121 @@
+//
+// Copyright (c) 2024 Quadient Group AG
+//
+// This file is subject to the terms and conditions defined in the
+// 'LICENSE' file found in the root of this source code package.
+//
+
+package config
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// Output formats.
+const (
+	FormatYAML = "yaml"
+	FormatJSON = "json"
+)
+
+// Origins of configuration values.
+const (
+	OriginDefault   = "default"
+	OriginFile      = "file"
+	OriginProfile   = "profile"
+	OriginEnv       = "env"
+	OriginFlag      = "flag"
+	OriginSecretDir = "secret-dir"
+	OriginKube      = "kubernetes"
+)
+
+// originValue is a JSON value annotated with its origin.
+type originValue struct {
+	Value  interface{} `json:"value"`
+	Origin string      `json:"origin"`
+}
+
+// Write renders the settings in the given format. If origins (keyed by
+// dotted key path) are given, each value is annotated with its origin:
+// as a comment in YAML and as a flat map of keys to values and origins
+// in JSON.
+func Write(w io.Writer, settings map[string]interface{}, format string, origins map[string]string) error {
+	switch strings.ToLower(format) {
+	case FormatYAML, "":
+		node, err := yamlNode(settings, "", origins)
+		if err != nil {
+			return err
+		}
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2) //nolint:gomnd // matches the configuration file.
+		if err = encoder.Encode(node); err != nil {
+			return errors.Wrap(err, "error encoding configuration")
+		}
+		return encoder.Close()
+	case FormatJSON:
+		var value interface{} = settings
+		if origins != nil {
+			annotated := map[string]originValue{}
+			for _, key := range Keys(settings) {
+				annotated[key] = originValue{Value: lookup(settings, key), Origin: origins[key]}
+			}
+			value = annotated
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return errors.Wrap(encoder.Encode(value), "error encoding configuration")
+	default:
+		return errors.Errorf("unsupported output format: %s", format)
+	}
+}
+
+// yamlNode builds a YAML mapping of the settings with sorted keys,
+// commenting each value with its origin.
+func yamlNode(settings map[string]interface{}, prefix string, origins map[string]string) (*yaml.Node, error) {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	names := make([]string, 0, len(settings))
+	for name := range settings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		path := joinKey(prefix, name)
+		key := &yaml.Node{Kind: yaml.ScalarNode, Value: name}
+		var value *yaml.Node
+		if child, ok := settings[name].(map[string]interface{}); ok && len(child) > 0 {
+			var err error
+			if value, err = yamlNode(child, path, origins); err != nil {
+				return nil, err
+			}
+		} else {
+			value = &yaml.Node{}
+			if err := value.Encode(settings[name]); err != nil {
+				return nil, errors.Wrap(err, "error encoding configuration")
+			}
+			if origin, ok := origins[path]; ok {
+				key.LineComment = origin
+			}
+		}
+		node.Content = append(node.Content, key, value)
+	}
+	return node, nil
+}
+
+// lookup returns the value at a dotted key path.
+func lookup(settings map[string]interface{}, key string) interface{} {
+	var value interface{} = settings
+	for _, name := range strings.Split(key, ".") {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = m[name]
+	}
+	return value
+}
@@ -0,0 +1,75 @@
+//
+// Copyright (c) 2024 Quadient Group AG
+//
+// This file is subject to the terms and conditions defined in the
+// 'LICENSE' file found in the root of this source code package.
+//
+
+package config_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/robertwtucker/spt-util/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsSecret(t *testing.T) {
+	assert.True(t, config.IsSecret("demo.password"))
+	assert.True(t, config.IsSecret("demo.pass"))
+	assert.True(t, config.IsSecret("custom.apiToken"))
+	assert.True(t, config.IsSecret("authHeader"))
+	assert.False(t, config.IsSecret("demo.username"))
+	assert.False(t, config.IsSecret("demo.stage.cacheDir"))
+	assert.False(t, config.IsSecret("demo.secretDir"))
+	assert.False(t, config.IsSecret("custom.tokenFile"))
+}
+
+func TestCanonicalizeAndRedact(t *testing.T) {
+	s := settings()
+	s["demo"].(map[string]interface{})["password"] = "secret"
+	s["demo"].(map[string]interface{})["username"] = "admin"
+
+	canonical, err := config.Canonicalize(s)
+	require.NoError(t, err)
+	demo := canonical["demo"].(map[string]interface{})
+	assert.Equal(t, "/deployment/env.json", demo["init"].(map[string]interface{})["envFile"])
+	assert.Equal(t, "5s", demo["stage"].(map[string]interface{})["progressInterval"])
+
+	redacted := config.Redact(canonical)
+	assert.Equal(t, config.Redacted, redacted["demo"].(map[string]interface{})["password"])
+	assert.Equal(t, "admin", redacted["demo"].(map[string]interface{})["username"])
+	assert.Equal(t, "secret", demo["password"], "the original settings are not modified")
+}
+
+func TestWrite(t *testing.T) {
+	settings := map[string]interface{}{
+		"global": map[string]interface{}{"release": "inspire"},
+		"demo":   map[string]interface{}{"server": "https://scaler.example.com"},
+	}
+	assert.Equal(t, []string{"demo.server", "global.release"}, config.Keys(settings))
+	origins := map[string]string{"demo.server": config.OriginEnv, "global.release": config.OriginFlag}
+
+	var out bytes.Buffer
+	require.NoError(t, config.Write(&out, settings, config.FormatYAML, origins))
+	assert.Equal(t, `demo:
+  server: https://scaler.example.com # env
+global:
+  release: inspire # flag
+`, out.String())
+
+	out.Reset()
+	require.NoError(t, config.Write(&out, settings, config.FormatJSON, origins))
+	assert.JSONEq(t, `{
+  "demo.server": {"value": "https://scaler.example.com", "origin": "env"},
+  "global.release": {"value": "inspire", "origin": "flag"}
+}`, out.String())
+
+	out.Reset()
+	require.NoError(t, config.Write(&out, settings, config.FormatJSON, nil))
+	assert.JSONEq(t, `{"demo": {"server": "https://scaler.example.com"}, "global": {"release": "inspire"}}`, out.String())
+
+	assert.Error(t, config.Write(&out, settings, "toml", nil))
+}
@@ -12,20 +12,61 @@ const AppName = "spt-util"
 
 // Setting keys.
 const (
+	ProfilesKey          = "profiles"
+	LogRedactFieldsKey   = "log.redact.fields"
+	LogRedactPatternsKey = "log.redact.patterns"
 	GlobalReleaseKey     = "global.release"
 	GlobalNamespaceKey   = "global.namespace"
+	MetricsPushURLKey    = "metrics.pushgateway"
+	MetricsJobKey        = "metrics.job"
+	TracingExporterKey   = "tracing.exporter"
+	TracingEndpointKey   = "tracing.endpoint"
+	EventsFileKey        = "events.file"
+	ServeAddressKey      = "serve.address"
+	ServeTokenKey        = "serve.token"
+	KubeDiscoverKey      = "kubernetes.discover"
+	KubeConfigKey        = "kubernetes.kubeconfig"
+	KubeContextKey       = "kubernetes.context"
+	KubeTimeoutKey       = "kubernetes.timeout"
 	DemoUsernameKey      = "demo.username"
 	DemoPasswordKey      = "demo.password"
 	DemoServerKey        = "demo.server"
+	DemoSecretDirKey     = "demo.secretDir"
+	DemoWaitEnabledKey   = "demo.wait.enabled"
+	DemoWaitTimeoutKey   = "demo.wait.timeout"
+	DemoWaitIntervalKey  = "demo.wait.interval"
+	DemoWaitHealthKey    = "demo.wait.healthPath"
+	DemoWaitVersionKey   = "demo.wait.versionPath"
+	DemoWaitAuthKey      = "demo.wait.authPath"
+	DemoWaitICMKey       = "demo.wait.icmPath"
 	DemoInitEnvFileKey   = "demo.init.envFile"
 	DemoInitChsFileKey   = "demo.init.chsFile"
 	DemoInitWorkflowsKey = "demo.init.workflows"
 	DemoStageFilesKey    = "demo.stage.files"
+	DemoStageCacheDirKey = "demo.stage.cacheDir"
+	DemoStageTimeoutKey  = "demo.stage.downloadTimeout"
+	DemoStageMaxEntryKey = "demo.stage.maxEntrySize"
+	DemoStageWorkersKey  = "demo.stage.workers"
+	DemoStageProgressKey = "demo.stage.progressInterval"
+	DemoStageDebounceKey = "demo.stage.watchDebounce"
+	DemoStageManifestKey = "demo.stage.manifest"
 )
 
 // Environment variables.
 const (
-	DemoUsernameEnv = "SCALER_USER"
-	DemoPasswordEnv = "SCALER_PASS"
-	DemoServerEnv   = "SCALER_URL"
+	DemoUsernameEnv  = "SCALER_USER"
+	DemoPasswordEnv  = "SCALER_PASS"
+	DemoServerEnv    = "SCALER_URL"
+	DemoSecretDirEnv = "SCALER_SECRET_DIR"
+	ProfileEnv       = "SPT_PROFILE"
+	ServeTokenEnv    = "SPT_SERVE_TOKEN"
+	MetricsPushEnv   = "PUSHGATEWAY_URL"
+	TracingEnv       = "OTEL_TRACES_EXPORTER"
+)
+
+// Environment variables used to authenticate remote staging sources.
+const (
+	StageHTTPTokenEnv    = "STAGE_HTTP_TOKEN"
+	StageHTTPUserEnv     = "STAGE_HTTP_USER"
+	StageHTTPPasswordEnv = "STAGE_HTTP_PASS"
 )
@@ -8,9 +8,14 @@
 package eventbus
 
 import (
+	"context"
 	"sync"
 
+	"github.com/robertwtucker/spt-util/pkg/metrics"
+	"github.com/robertwtucker/spt-util/pkg/tracing"
 	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Event holds the name of an event and its associated data.
@@ -18,10 +23,25 @@ type Event struct {
 	Data interface{}
 	Name string
 	wg   *sync.WaitGroup
+	ctx  context.Context
+	span trace.Span
 }
 
-// Done wraps the WaitGroup.Done() call.
+// Context returns the context the Event should be handled in. When the
+// Event was published with a traced context, it carries the span of the
+// subscriber handling the Event.
+func (e *Event) Context() context.Context {
+	if e.ctx == nil {
+		return context.Background()
+	}
+	return e.ctx
+}
+
+// Done wraps the WaitGroup.Done() call and ends the handling span.
 func (e *Event) Done() {
+	if e.span != nil {
+		e.span.End()
+	}
 	if e.wg != nil {
 		log.WithField("event", e.Name).Debug("marking event done")
 		e.wg.Done()
@@ -36,71 +56,203 @@ func NewEventChannel() EventChannel {
 	return make(EventChannel)
 }
 
+// NewBufferedEventChannel creates a new EventChannel buffering up to
+// size Events.
+func NewBufferedEventChannel(size int) EventChannel {
+	return make(EventChannel, size)
+}
+
 // eventChannelSlice is a slice of channels that accept an Event.
 type eventChannelSlice []EventChannel
 
 // CallbackFunction defines a callback function for the named Event.
 type CallbackFunction func(name string, data interface{})
 
+// patternSubscriber is an EventChannel subscribed to a topic pattern.
+type patternSubscriber struct {
+	pattern string
+	channel EventChannel
+}
+
 // EventBus stores the mapping of subscribers (instances of EventChannel)
-// to a corresponding event name.
+// to a corresponding event name or topic pattern, and how Events are
+// delivered to each.
 type EventBus struct {
 	mutex       sync.RWMutex
 	subscribers map[string]eventChannelSlice
+	patterns    []patternSubscriber
+	delivery    map[EventChannel]*subscriber
+	store       Store
 }
 
 // NewEventBus creates a new EventBus.
 func NewEventBus() *EventBus {
 	return &EventBus{
 		subscribers: make(map[string]eventChannelSlice),
+		delivery:    make(map[EventChannel]*subscriber),
+	}
+}
+
+// SetStore records the Events published from now on in store.
+func (eb *EventBus) SetStore(store Store) {
+	eb.mutex.Lock()
+	defer eb.mutex.Unlock()
+	eb.store = store
+}
+
+// record appends the Event to the store, if any. Failures are logged
+// and do not affect the delivery of the Event.
+func (eb *EventBus) record(event Event) {
+	eb.mutex.RLock()
+	store := eb.store
+	eb.mutex.RUnlock()
+	if store == nil {
+		return
+	}
+	if err := store.Append(event); err != nil {
+		log.WithField("event", event.Name).Warn("error recording event: ", err)
 	}
 }
 
-// getEventSubscribers returns the EventChannel(s) subscribed the named Event.
-func (eb *EventBus) getEventSubscribers(name string) eventChannelSlice {
-	subscribers := eventChannelSlice{}
+// getEventSubscribers returns the subscribers of the named Event,
+// subscribed directly or through a matching topic pattern. Channels
+// matching more than one subscription are returned once.
+func (eb *EventBus) getEventSubscribers(name string) []*subscriber {
+	eb.mutex.RLock()
+	defer eb.mutex.RUnlock()
+
+	subscribers := []*subscriber{}
+	seen := make(map[EventChannel]bool)
+	add := func(ec EventChannel) {
+		if !seen[ec] {
+			seen[ec] = true
+			subscribers = append(subscribers, eb.delivery[ec])
+		}
+	}
 
-	if len(eb.subscribers[name]) > 0 {
-		subscribers = append(subscribers, eb.subscribers[name]...)
+	for _, ec := range eb.subscribers[name] {
+		add(ec)
+	}
+	for _, p := range eb.patterns {
+		if Match(p.pattern, name) {
+			add(p.channel)
+		}
 	}
 
 	return subscribers
 }
 
-// HasSubscribers returns true if the named Event has subscribers.
+// HasSubscribers returns true if the named Event has subscribers, or if
+// name is a topic pattern, whether it has been subscribed to.
 func (eb *EventBus) HasSubscribers(name string) bool {
-	return len(eb.subscribers[name]) > 0
+	if IsPattern(name) {
+		eb.mutex.RLock()
+		defer eb.mutex.RUnlock()
+		for _, p := range eb.patterns {
+			if p.pattern == name {
+				return true
+			}
+		}
+		return false
+	}
+	return len(eb.getEventSubscribers(name)) > 0
 }
 
-// publish sends an Event to subscribed channels ([]EventChannel).
-func (eb *EventBus) publish(channels []EventChannel, event Event) {
-	eb.mutex.RLock()
-	defer eb.mutex.RUnlock()
-
-	go func(channels []EventChannel, event Event) {
-		for i, channel := range channels {
-			log.WithFields(log.Fields{
-				"event": event.Name,
-				"chan":  channel,
-			}).Debugf("sending event to subscriber[%d]", i+1)
-			channel <- event
+// publish hands an Event to each subscriber without blocking, so that
+// neither the publisher nor the other subscribers wait for a slow one.
+// It returns the channels receiving the outcome of the deliveries that
+// were queued and the first error applying a full buffer policy.
+func (eb *EventBus) publish(subscribers []*subscriber, event Event) ([]<-chan error, error) {
+	var queued []<-chan error
+	var err error
+	for i, s := range subscribers {
+		log.WithFields(log.Fields{
+			"event": event.Name,
+			"chan":  s.channel,
+		}).Debugf("sending event to subscriber[%d]", i+1)
+		result, deliverErr := s.deliver(handled(event, i+1))
+		if result != nil {
+			queued = append(queued, result)
 		}
-	}(channels, event)
+		if err == nil {
+			err = deliverErr
+		}
+	}
+	return queued, err
+}
+
+// handled returns a copy of the Event for the nth subscriber. If the
+// Event is traced, the copy carries a new span for its handling, which
+// Done() ends.
+func handled(event Event, n int) Event {
+	if !trace.SpanContextFromContext(event.ctx).IsValid() {
+		return event
+	}
+	event.ctx, event.span = tracing.Tracer().Start(event.ctx, "handle "+event.Name,
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(attribute.String("event", event.Name), attribute.Int("subscriber", n)),
+	)
+	return event
+}
+
+// startPublish starts the span of publishing the named Event if ctx is
+// traced. Events published with an untraced context are not traced.
+func startPublish(ctx context.Context, name string, subscribers int) (context.Context, trace.Span) {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return ctx, nil
+	}
+	return tracing.Tracer().Start(ctx, "publish "+name,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(attribute.String("event", name), attribute.Int("subscribers", subscribers)),
+	)
 }
 
 // PublishEvent sends data to all named Event subscribers. It waits
 // for all subscribers to finish (each must call Done() on Event).
+// Delivery errors are logged.
 func (eb *EventBus) PublishEvent(name string, data interface{}) {
+	if err := eb.PublishEventContext(context.Background(), name, data); err != nil {
+		log.WithField("event", name).Error("error publishing event: ", err)
+	}
+}
+
+// PublishEventContext is PublishEvent with a context: the trace context
+// of ctx is propagated to the subscribers through Event.Context(), and
+// publishing gives up waiting for blocked subscribers once ctx is done.
+// It returns the first error delivering the Event; the subscribers that
+// accepted it are still waited for.
+func (eb *EventBus) PublishEventContext(ctx context.Context, name string, data interface{}) error {
+	return eb.publishSync(ctx, name, data, true)
+}
+
+// publishSync publishes an Event and waits for its subscribers, adding
+// it to the store if record is true.
+func (eb *EventBus) publishSync(ctx context.Context, name string, data interface{}, record bool) error {
 	wg := sync.WaitGroup{}
 	subscribers := eb.getEventSubscribers(name)
+	metrics.EventsPublished.WithLabelValues(name).Inc()
 	wg.Add(len(subscribers))
+	ctx, span := startPublish(ctx, name, len(subscribers))
+	if span != nil {
+		defer span.End()
+	}
 
 	log.WithFields(log.Fields{
 		"event":       name,
 		"subscribers": len(subscribers),
 		"mode":        "sync",
 	}).Debug("publishing event")
-	eb.publish(subscribers, Event{Data: data, Name: name, wg: &wg})
+	event := Event{Data: data, Name: name, wg: &wg, ctx: ctx}
+	if record {
+		eb.record(event)
+	}
+	queued, err := eb.publish(subscribers, event)
+	for _, result := range queued {
+		if resultErr := <-result; err == nil {
+			err = resultErr
+		}
+	}
+	tracing.Fail(span, err)
 
 	log.WithFields(log.Fields{
 		"event":       name,
@@ -109,38 +261,67 @@ func (eb *EventBus) PublishEvent(name string, data interface{}) {
 	wg.Wait()
 
 	log.WithField("event", name).Debug("subscribers have finished")
+	return err
 }
 
 // PublishEventAsync sends data to all named Event subscribers
-// asynchronously. Subscribers are expected to manage their lifecycle.
+// asynchronously: it does not wait for the subscribers to accept or
+// handle the Event. Subscribers are expected to manage their lifecycle.
+// Delivery errors are logged.
 func (eb *EventBus) PublishEventAsync(name string, data interface{}) {
+	if err := eb.PublishEventAsyncContext(context.Background(), name, data); err != nil {
+		log.WithField("event", name).Error("error publishing event: ", err)
+	}
+}
+
+// PublishEventAsyncContext is PublishEventAsync with a context: the
+// trace context of ctx is propagated to the subscribers through
+// Event.Context(), and the Event is no longer delivered to subscribers
+// it is queued for once ctx is done. It returns the first error applying
+// a full buffer policy.
+func (eb *EventBus) PublishEventAsyncContext(ctx context.Context, name string, data interface{}) error {
 	subscribers := eb.getEventSubscribers(name)
+	metrics.EventsPublished.WithLabelValues(name).Inc()
+	ctx, span := startPublish(ctx, name, len(subscribers))
+	if span != nil {
+		defer span.End()
+	}
 
 	log.WithFields(log.Fields{
 		"event":       name,
 		"subscribers": len(subscribers),
 		"mode":        "async",
 	}).Debug("publishing event")
-	eb.publish(
-		subscribers,
-		Event{Data: data, Name: name, wg: nil},
-	)
+	event := Event{Data: data, Name: name, wg: nil, ctx: ctx}
+	eb.record(event)
+	_, err := eb.publish(subscribers, event)
+	tracing.Fail(span, err)
+	return err
 }
 
-// SubscribeEvent returns an EventChannel subscribed to the named Event.
+// SubscribeEvent returns an unbuffered EventChannel subscribed to the
+// named Event or to the Events matching a topic pattern (see Match).
 func (eb *EventBus) SubscribeEvent(name string) EventChannel {
-	ec := NewEventChannel()
+	return eb.SubscribeEventWithOptions(name, SubscribeOptions{})
+}
+
+// SubscribeEventWithOptions is SubscribeEvent with a channel buffering
+// options.Buffer Events and delivery as configured by the options.
+func (eb *EventBus) SubscribeEventWithOptions(name string, options SubscribeOptions) EventChannel {
+	ec := NewBufferedEventChannel(options.Buffer)
 
 	log.WithFields(log.Fields{
-		"event": name,
-		"chan":  ec,
+		"event":  name,
+		"chan":   ec,
+		"buffer": options.Buffer,
 	}).Debug("subscribing to event")
-	eb.SubscribeEventChannel(ec, name)
+	eb.SubscribeEventChannelWithOptions(ec, name, options)
 
 	return ec
 }
 
-// SubscribeEventCallback registers a callback in response to an Event.
+// SubscribeEventCallback registers a callback in response to an Event
+// (the first Event matching name, if it is a topic pattern).
 func (eb *EventBus) SubscribeEventCallback(name string, callback CallbackFunction) {
 	ec := eb.SubscribeEvent(name)
 
@@ -162,20 +343,47 @@ func (eb *EventBus) SubscribeEventCallback(name string, callback CallbackFunctio
 	}(callback)
 }
 
-// SubscribeEventChannel registers an EventChannel's subscription to an Event.
+// SubscribeEventChannel registers an EventChannel's subscription to an
+// Event or, if name is a topic pattern, to the Events matching it.
+// Events wait, in order, for room in the channel's buffer, if any.
+// Subscriptions to malformed patterns are rejected and logged.
 func (eb *EventBus) SubscribeEventChannel(ec EventChannel, name string) {
+	eb.SubscribeEventChannelWithOptions(ec, name, SubscribeOptions{})
+}
+
+// SubscribeEventChannelWithOptions is SubscribeEventChannel with
+// delivery as configured by the options; the buffer is that of ec.
+// The options apply to all subscriptions of the channel, the last ones
+// given replacing those of its earlier subscriptions. Subscriptions with
+// an unknown policy are rejected and logged.
+func (eb *EventBus) SubscribeEventChannelWithOptions(ec EventChannel, name string, options SubscribeOptions) {
+	err := validatePolicy(options.Policy)
+	if err == nil && IsPattern(name) {
+		err = ValidatePattern(name)
+	}
+	if err != nil {
+		log.WithField("chan", ec).Error("error subscribing to event: ", err)
+		return
+	}
+
 	eb.mutex.Lock()
 	defer eb.mutex.Unlock()
 
-	if subscribers, found := eb.subscribers[name]; found {
+	eb.delivery[ec] = newSubscriber(ec, options)
+
+	if IsPattern(name) {
+		eb.patterns = append(eb.patterns, patternSubscriber{pattern: name, channel: ec})
+	} else if subscribers, found := eb.subscribers[name]; found {
 		eb.subscribers[name] = append(subscribers, ec)
 	} else {
 		eb.subscribers[name] = append(eventChannelSlice{}, ec)
 	}
+	metrics.EventSubscriptions.WithLabelValues(name).Inc()
 
 	log.WithFields(log.Fields{
 		"event":       name,
 		"chan":        ec,
 		"subscribers": len(eb.subscribers[name]),
+		"pattern":     IsPattern(name),
 	}).Debug("added subcriber")
 }
@@ -8,11 +8,15 @@
 package eventbus_test
 
 import (
+	"context"
 	"sync"
 	"testing"
 
 	"github.com/robertwtucker/spt-util/pkg/eventbus"
 	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func TestEventBus_NewEventBus(t *testing.T) {
@@ -99,3 +103,36 @@ func TestEventBus_SubscribeEventCallback(t *testing.T) {
 
 	eb.PublishEvent(eventName, eventData)
 }
+
+func TestEventBus_PublishEventContext(t *testing.T) {
+	previous := otel.GetTracerProvider()
+	provider := sdktrace.NewTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previous)
+		assert.NoError(t, provider.Shutdown(context.Background()))
+	})
+	eventName := "foo"
+
+	eb := eventbus.NewEventBus()
+	ec := eb.SubscribeEvent(eventName)
+
+	var handled trace.SpanContext
+	go func() {
+		for event := range ec {
+			handled = trace.SpanContextFromContext(event.Context())
+			event.Done()
+		}
+	}()
+
+	// Untraced events are handled without a span.
+	eb.PublishEvent(eventName, "bar")
+	assert.False(t, handled.IsValid())
+
+	ctx, span := otel.Tracer("test").Start(context.Background(), "command")
+	defer span.End()
+	eb.PublishEventContext(ctx, eventName, "bar")
+	assert.True(t, handled.IsValid())
+	assert.Equal(t, span.SpanContext().TraceID(), handled.TraceID())
+	assert.NotEqual(t, span.SpanContext().SpanID(), handled.SpanID())
+}
@@ -12,3 +12,11 @@ const InitDeployScalerWorkflows = "init-deploy-scaler-workflows"
 const InitFindScalerWorkflows = "init-find-scaler-workflows"
 const InitStart = "init-start"
 const InitUploadChangeSet = "init-upload-changeset"
+
+// Events for jobs run by the serve command.
+const JobLog = "job-log"
+const JobStatus = "job-status"
+const JobStep = "job-step"
+
+// JobEvents is the topic pattern matching the events of jobs.
+const JobEvents = "job-*"
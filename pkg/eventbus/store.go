@@ -0,0 +1,238 @@
+//
+// Copyright (c) 2024 Quadient Group AG
+//
+// This file is subject to the terms and conditions defined in the
+// 'LICENSE' file found in the root of this source code package.
+//
+
+package eventbus
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Record output formats.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// maxRecordSize limits the size of a line of an event log.
+const maxRecordSize = 4 << 20
+
+// Record is an Event recorded in an event log.
+type Record struct {
+	Run     string          `json:"run"`
+	Command string          `json:"command,omitempty"`
+	Seq     int             `json:"seq"`
+	Time    time.Time       `json:"time"`
+	Event   string          `json:"event"`
+	Data    json.RawMessage `json:"data,omitempty"`
+	TraceID string          `json:"traceId,omitempty"`
+}
+
+// Store records the Events published on an EventBus.
+type Store interface {
+	Append(event Event) error
+}
+
+// Redactor returns a copy of Event data with its secrets redacted.
+type Redactor func(data interface{}) interface{}
+
+// FileStore is a Store appending the Events of a run as JSON lines to
+// an event log file. Several runs may append to the same file.
+type FileStore struct {
+	mutex   sync.Mutex
+	file    *os.File
+	run     string
+	command string
+	redact  Redactor
+	seq     int
+}
+
+// OpenFileStore opens the event log at path, creating it if needed, to
+// record the Events of the named run of a command. Event data is
+// redacted with redact, if not nil, before it is written.
+func OpenFileStore(path string, run string, command string, redact Redactor) (*FileStore, error) {
+	//nolint:gomnd // the log may hold data of the demo environment.
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, errors.Wrap(err, "error creating event log directory")
+	}
+	//nolint:gosec,gomnd // path is configured by the user.
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, errors.Wrap(err, "error opening event log")
+	}
+	return &FileStore{file: file, run: run, command: command, redact: redact}, nil
+}
+
+// Append implements Store.
+func (s *FileStore) Append(event Event) error {
+	data, err := encodeData(event.Data, s.redact)
+	if err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.seq++
+	record := Record{
+		Run:     s.run,
+		Command: s.command,
+		Seq:     s.seq,
+		Time:    time.Now().UTC(),
+		Event:   event.Name,
+		Data:    data,
+	}
+	if sc := trace.SpanContextFromContext(event.Context()); sc.IsValid() {
+		record.TraceID = sc.TraceID().String()
+	}
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		return errors.Wrap(err, "error encoding event record")
+	}
+	if _, err = s.file.Write(append(line, '\n')); err != nil {
+		return errors.Wrap(err, "error writing event log")
+	}
+	return nil
+}
+
+// Close closes the event log.
+func (s *FileStore) Close() error {
+	return s.file.Close()
+}
+
+// encodeData returns the JSON encoding of Event data. Data that is
+// already JSON ([]byte) is decoded first so that it can be redacted;
+// other []byte data is recorded as a string.
+func encodeData(data interface{}, redact Redactor) (json.RawMessage, error) {
+	if data == nil {
+		return nil, nil
+	}
+	if raw, ok := data.([]byte); ok {
+		var decoded interface{}
+		if err := json.Unmarshal(raw, &decoded); err != nil {
+			decoded = string(raw)
+		}
+		data = decoded
+	}
+	if redact != nil {
+		data = redact(data)
+	}
+
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return nil, errors.Wrap(err, "error encoding event data")
+	}
+	return encoded, nil
+}
+
+// ReadRecords reads the records of an event log.
+func ReadRecords(r io.Reader) ([]Record, error) {
+	records := []Record{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, maxRecordSize)
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		record := Record{}
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, errors.Wrapf(err, "error decoding event record on line %d", line)
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "error reading event log")
+	}
+	return records, nil
+}
+
+// Runs returns the runs of the records in the order they started.
+func Runs(records []Record) []string {
+	runs := []string{}
+	seen := map[string]bool{}
+	for _, record := range records {
+		if !seen[record.Run] {
+			seen[record.Run] = true
+			runs = append(runs, record.Run)
+		}
+	}
+	return runs
+}
+
+// FilterRecords returns the records of a run (all runs, if empty) whose
+// Events match a topic pattern (all Events, if empty).
+func FilterRecords(records []Record, run string, pattern string) []Record {
+	filtered := []Record{}
+	for _, record := range records {
+		if run != "" && record.Run != run {
+			continue
+		}
+		if pattern != "" && !Match(pattern, record.Event) {
+			continue
+		}
+		filtered = append(filtered, record)
+	}
+	return filtered
+}
+
+// WriteRecords writes records as a table (FormatText) or as JSON lines
+// (FormatJSON).
+func WriteRecords(w io.Writer, records []Record, format string) error {
+	switch strings.ToLower(format) {
+	case FormatText, "":
+		//nolint:gomnd // column padding.
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "TIME\tRUN\tSEQ\tEVENT\tDATA")
+		for _, record := range records {
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%s\n",
+				record.Time.Format(time.RFC3339Nano), record.Run, record.Seq, record.Event, record.Data)
+		}
+		return errors.Wrap(tw.Flush(), "error writing event records")
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		for _, record := range records {
+			if err := encoder.Encode(record); err != nil {
+				return errors.Wrap(err, "error writing event records")
+			}
+		}
+		return nil
+	default:
+		return errors.Errorf("unsupported output format: %s", format)
+	}
+}
+
+// Replay re-publishes recorded Events to the subscribers in the order
+// of the records, waiting for each to be handled as PublishEvent does.
+// The data of the Events is the recorded JSON as []byte, with secrets
+// redacted. Replayed Events are not recorded again.
+func (eb *EventBus) Replay(ctx context.Context, records []Record) error {
+	for _, record := range records {
+		if err := ctx.Err(); err != nil {
+			return errors.Wrap(err, "replay interrupted")
+		}
+		var data interface{}
+		if len(record.Data) > 0 {
+			data = []byte(record.Data)
+		}
+		if err := eb.publishSync(ctx, record.Event, data, false); err != nil {
+			return errors.Wrapf(err, "error replaying event %d of run %s", record.Seq, record.Run)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+//
+// Copyright (c) 2024 Quadient Group AG
+//
+// This file is subject to the terms and conditions defined in the
+// 'LICENSE' file found in the root of this source code package.
+//
+
+package eventbus_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/robertwtucker/spt-util/pkg/eventbus"
+	"github.com/robertwtucker/spt-util/pkg/redact"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// record publishes events on a bus recording them in the event log at
+// path as the given run.
+func record(t *testing.T, path string, run string) {
+	t.Helper()
+	hook, err := redact.NewHook(nil, nil)
+	require.NoError(t, err)
+	store, err := eventbus.OpenFileStore(path, run, "demo init", hook.Value)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, store.Close()) }()
+
+	eb := eventbus.NewEventBus()
+	eb.SetStore(store)
+	eb.PublishEvent(eventbus.InitStart, []byte(`{"authHeader":"Basic dXNlcjpwYXNz","scalerHost":"http://scaler"}`))
+	eb.PublishEventAsync(eventbus.InitFindScalerWorkflows, map[string]int{"workflows": 2})
+	eb.PublishEvent(eventbus.InitDeployScalerWorkflows, nil)
+}
+
+// readRecords reads the event log at path.
+func readRecords(t *testing.T, path string) []eventbus.Record {
+	t.Helper()
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer func() { _ = file.Close() }()
+	records, err := eventbus.ReadRecords(file)
+	require.NoError(t, err)
+	return records
+}
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log", "events.jsonl")
+	record(t, path, "run-1")
+	record(t, path, "run-2")
+
+	records := readRecords(t, path)
+	require.Len(t, records, 6)
+	assert.Equal(t, []string{"run-1", "run-2"}, eventbus.Runs(records))
+
+	first := records[0]
+	assert.Equal(t, "run-1", first.Run)
+	assert.Equal(t, "demo init", first.Command)
+	assert.Equal(t, 1, first.Seq)
+	assert.Equal(t, eventbus.InitStart, first.Event)
+	assert.JSONEq(t, `{"authHeader":"[REDACTED]","scalerHost":"http://scaler"}`, string(first.Data))
+	assert.False(t, first.Time.IsZero())
+
+	assert.JSONEq(t, `{"workflows":2}`, string(records[1].Data))
+	assert.Empty(t, records[2].Data)
+	assert.Equal(t, 3, records[2].Seq)
+	assert.Equal(t, 1, records[3].Seq)
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(content), "dXNlcjpwYXNz")
+}
+
+func TestReadRecords_Invalid(t *testing.T) {
+	_, err := eventbus.ReadRecords(strings.NewReader("{\"run\":\"1\"}\n\nnot json\n"))
+	assert.ErrorContains(t, err, "line 3")
+}
+
+func TestFilterRecords(t *testing.T) {
+	records := []eventbus.Record{
+		{Run: "1", Event: eventbus.InitStart},
+		{Run: "1", Event: eventbus.JobLog},
+		{Run: "2", Event: eventbus.InitStart},
+	}
+
+	assert.Len(t, eventbus.FilterRecords(records, "", ""), 3)
+	assert.Len(t, eventbus.FilterRecords(records, "1", ""), 2)
+	assert.Equal(t, []eventbus.Record{records[1]}, eventbus.FilterRecords(records, "", "job-*"))
+}
+
+func TestWriteRecords(t *testing.T) {
+	records := []eventbus.Record{{Run: "1", Seq: 1, Event: eventbus.InitStart, Data: []byte(`{"a":1}`)}}
+
+	out := &bytes.Buffer{}
+	require.NoError(t, eventbus.WriteRecords(out, records, eventbus.FormatText))
+	assert.Contains(t, out.String(), "EVENT")
+	assert.Contains(t, out.String(), `init-start  {"a":1}`)
+
+	out.Reset()
+	require.NoError(t, eventbus.WriteRecords(out, records, eventbus.FormatJSON))
+	assert.Contains(t, out.String(), `"data":{"a":1}`)
+
+	assert.Error(t, eventbus.WriteRecords(out, records, "xml"))
+}
+
+func TestEventBus_Replay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	record(t, path, "run-1")
+	records := readRecords(t, path)
+
+	store, err := eventbus.OpenFileStore(path, "replay", "", nil)
+	require.NoError(t, err)
+	defer func() { _ = store.Close() }()
+	eb := eventbus.NewEventBus()
+	eb.SetStore(store)
+	ec := eb.SubscribeEvent("init-*")
+
+	replayed := []eventbus.Event{}
+	go func() {
+		for event := range ec {
+			replayed = append(replayed, event)
+			event.Done()
+		}
+	}()
+	require.NoError(t, eb.Replay(context.Background(), records))
+
+	require.Len(t, replayed, 3)
+	assert.Equal(t, eventbus.InitStart, replayed[0].Name)
+	assert.JSONEq(t, `{"authHeader":"[REDACTED]","scalerHost":"http://scaler"}`, string(replayed[0].Data.([]byte)))
+	assert.Nil(t, replayed[2].Data)
+	// Replayed events are not recorded again.
+	assert.Len(t, readRecords(t, path), 3)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Error(t, eb.Replay(ctx, records))
+}
@@ -0,0 +1,228 @@
+//
+// Copyright (c) 2024 Quadient Group AG
+//
+// This file is subject to the terms and conditions defined in the
+// 'LICENSE' file found in the root of this source code package.
+//
+
+package eventbus
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/robertwtucker/spt-util/pkg/metrics"
+	log "github.com/sirupsen/logrus"
+)
+
+// Policy decides what happens to an Event published to a subscriber
+// whose buffer is full.
+type Policy string
+
+// Full buffer policies.
+const (
+	// PolicyBlock queues the Event until there is room in the buffer.
+	// Queued Events are delivered in order by a goroutine of the
+	// subscriber, so the publisher does not wait for them.
+	PolicyBlock Policy = "block"
+	// PolicyDropOldest drops the oldest buffered Event to make room.
+	// Without a buffer, the published Event is dropped instead.
+	PolicyDropOldest Policy = "drop-oldest"
+	// PolicyDropNewest drops the published Event.
+	PolicyDropNewest Policy = "drop-newest"
+	// PolicyError drops the published Event and fails the publish
+	// with ErrBufferFull.
+	PolicyError Policy = "error"
+)
+
+// DefaultLagThreshold is how long a queued Event may wait for a
+// subscriber before the subscriber is reported as lagging.
+const DefaultLagThreshold = 5 * time.Second
+
+// ErrBufferFull is returned when publishing to a subscriber with the
+// PolicyError policy whose buffer is full.
+var ErrBufferFull = errors.New("subscriber buffer full")
+
+// SubscribeOptions configure the delivery of Events to a subscriber.
+type SubscribeOptions struct {
+	// Buffer is the number of Events the subscription channel buffers
+	// (0 for an unbuffered channel). It applies to the channels created
+	// by SubscribeEventWithOptions.
+	Buffer int
+	// Policy applies when the buffer is full (default PolicyBlock).
+	Policy Policy
+	// LagThreshold is how long a queued Event waits before the
+	// subscriber is reported as lagging (default DefaultLagThreshold,
+	// negative to disable).
+	LagThreshold time.Duration
+}
+
+// subscriber delivers Events to a subscribed EventChannel.
+type subscriber struct {
+	channel      EventChannel
+	policy       Policy
+	lagThreshold time.Duration
+	mutex        sync.Mutex // guards queue, serializes making room in the buffer
+	queue        []queuedEvent
+}
+
+// queuedEvent is an Event waiting for room in a subscriber's buffer.
+// The outcome of its delivery is sent to result.
+type queuedEvent struct {
+	event  Event
+	result chan error
+}
+
+// newSubscriber returns a subscriber delivering to ec with the options
+// given, defaults applied.
+func newSubscriber(ec EventChannel, options SubscribeOptions) *subscriber {
+	s := &subscriber{channel: ec, policy: options.Policy, lagThreshold: options.LagThreshold}
+	if s.policy == "" {
+		s.policy = PolicyBlock
+	}
+	if s.lagThreshold == 0 {
+		s.lagThreshold = DefaultLagThreshold
+	}
+	return s
+}
+
+// validatePolicy returns an error if the policy is unknown.
+func validatePolicy(policy Policy) error {
+	switch policy {
+	case "", PolicyBlock, PolicyDropOldest, PolicyDropNewest, PolicyError:
+		return nil
+	default:
+		return errors.Errorf("unsupported full buffer policy: %s", policy)
+	}
+}
+
+// deliver hands the Event to the subscriber without blocking, applying
+// its policy if the buffer is full. Dropped Events are marked done. An
+// Event queued by PolicyBlock is delivered by run, which sends the
+// outcome to the channel returned (nil if the Event was not queued).
+func (s *subscriber) deliver(event Event) (<-chan error, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// Queued Events go first, so that the subscriber gets Events in the
+	// order they were published.
+	if len(s.queue) == 0 {
+		select {
+		case s.channel <- event:
+			return nil, nil
+		default:
+		}
+	}
+
+	switch s.policy {
+	case PolicyDropNewest:
+		s.drop(event, string(s.policy))
+		return nil, nil
+	case PolicyDropOldest:
+		s.dropOldest(event)
+		return nil, nil
+	case PolicyError:
+		s.drop(event, string(s.policy))
+		return nil, errors.Wrapf(ErrBufferFull, "error delivering event %s", event.Name)
+	default:
+		result := make(chan error, 1)
+		s.queue = append(s.queue, queuedEvent{event: event, result: result})
+		if len(s.queue) == 1 {
+			go s.run()
+		}
+		return result, nil
+	}
+}
+
+// run delivers the queued Events in order until the queue is empty. An
+// Event stays at the head of the queue until it is delivered, so that
+// deliver does not pass it.
+func (s *subscriber) run() {
+	s.mutex.Lock()
+	for len(s.queue) > 0 {
+		next := s.queue[0]
+		s.mutex.Unlock()
+		next.result <- s.wait(next.event.Context(), next.event)
+		s.mutex.Lock()
+		s.queue[0] = queuedEvent{}
+		s.queue = s.queue[1:]
+	}
+	s.mutex.Unlock()
+}
+
+// wait blocks until the subscriber accepts the Event or ctx is done,
+// reporting the subscriber once the Event has waited longer than the
+// lag threshold.
+func (s *subscriber) wait(ctx context.Context, event Event) error {
+	var lag <-chan time.Time
+	if s.lagThreshold > 0 {
+		timer := time.NewTimer(s.lagThreshold)
+		defer timer.Stop()
+		lag = timer.C
+	}
+
+	start := time.Now()
+	lagging := false
+	for {
+		select {
+		case s.channel <- event:
+			if lagging {
+				log.WithFields(log.Fields{
+					"event":  event.Name,
+					"chan":   s.channel,
+					"waited": time.Since(start).String(),
+				}).Info("lagging subscriber accepted event")
+			}
+			return nil
+		case <-lag:
+			lag = nil
+			lagging = true
+			metrics.SlowSubscribers.WithLabelValues(event.Name).Inc()
+			log.WithFields(log.Fields{
+				"event":     event.Name,
+				"chan":      s.channel,
+				"buffered":  len(s.channel),
+				"capacity":  cap(s.channel),
+				"threshold": s.lagThreshold.String(),
+			}).Warn("subscriber is lagging: event not accepted within threshold")
+		case <-ctx.Done():
+			s.drop(event, "canceled")
+			return errors.Wrapf(ctx.Err(), "error delivering event %s", event.Name)
+		}
+	}
+}
+
+// dropOldest drops buffered Events until the subscriber has room for
+// the Event. The caller holds s.mutex.
+func (s *subscriber) dropOldest(event Event) {
+	for {
+		select {
+		case s.channel <- event:
+			return
+		default:
+		}
+		select {
+		case oldest := <-s.channel:
+			s.drop(oldest, string(s.policy))
+		default:
+			// Nothing buffered to drop.
+			s.drop(event, string(s.policy))
+			return
+		}
+	}
+}
+
+// drop marks an Event that will not be delivered as done, so that a
+// synchronous publisher does not wait for it.
+func (s *subscriber) drop(event Event, reason string) {
+	metrics.EventsDropped.WithLabelValues(event.Name, reason).Inc()
+	log.WithFields(log.Fields{
+		"event":    event.Name,
+		"chan":     s.channel,
+		"reason":   reason,
+		"capacity": cap(s.channel),
+	}).Warn("dropped event")
+	event.Done()
+}
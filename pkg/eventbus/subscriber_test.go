@@ -0,0 +1,165 @@
+//
+// Copyright (c) 2024 Quadient Group AG
+//
+// This file is subject to the terms and conditions defined in the
+// 'LICENSE' file found in the root of this source code package.
+//
+
+package eventbus_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/robertwtucker/spt-util/pkg/eventbus"
+	"github.com/robertwtucker/spt-util/pkg/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// buffered returns the data of the Events buffered in ec.
+func buffered(ec eventbus.EventChannel) []interface{} {
+	data := []interface{}{}
+	for len(ec) > 0 {
+		event := <-ec
+		data = append(data, event.Data)
+		event.Done()
+	}
+	return data
+}
+
+func TestEventBus_SubscribeBuffered(t *testing.T) {
+	eb := eventbus.NewEventBus()
+	ec := eb.SubscribeEventWithOptions("buffered", eventbus.SubscribeOptions{Buffer: 2})
+
+	// Publishing returns once the Events are buffered.
+	eb.PublishEventAsync("buffered", 1)
+	eb.PublishEventAsync("buffered", 2)
+
+	assert.Equal(t, 2, cap(ec))
+	assert.Equal(t, []interface{}{1, 2}, buffered(ec))
+}
+
+func TestEventBus_PolicyDropNewest(t *testing.T) {
+	eb := eventbus.NewEventBus()
+	ec := eb.SubscribeEventWithOptions("drop-newest", eventbus.SubscribeOptions{
+		Buffer: 1, Policy: eventbus.PolicyDropNewest,
+	})
+	dropped := metrics.EventsDropped.WithLabelValues("drop-newest", "drop-newest")
+	before := testutil.ToFloat64(dropped)
+
+	for i := 1; i <= 3; i++ {
+		require.NoError(t, eb.PublishEventAsyncContext(context.Background(), "drop-newest", i))
+	}
+	// Dropped Events are done, so synchronous publishers don't wait.
+	eb.PublishEvent("drop-newest", 4)
+
+	assert.Equal(t, []interface{}{1}, buffered(ec))
+	assert.Equal(t, before+3, testutil.ToFloat64(dropped))
+}
+
+func TestEventBus_PolicyDropOldest(t *testing.T) {
+	eb := eventbus.NewEventBus()
+	ec := eb.SubscribeEventWithOptions("drop-oldest", eventbus.SubscribeOptions{
+		Buffer: 2, Policy: eventbus.PolicyDropOldest,
+	})
+
+	for i := 1; i <= 5; i++ {
+		require.NoError(t, eb.PublishEventAsyncContext(context.Background(), "drop-oldest", i))
+	}
+
+	assert.Equal(t, []interface{}{4, 5}, buffered(ec))
+}
+
+func TestEventBus_PolicyError(t *testing.T) {
+	eb := eventbus.NewEventBus()
+	ec := eb.SubscribeEventWithOptions("error", eventbus.SubscribeOptions{
+		Buffer: 1, Policy: eventbus.PolicyError,
+	})
+
+	require.NoError(t, eb.PublishEventAsyncContext(context.Background(), "error", 1))
+	err := eb.PublishEventAsyncContext(context.Background(), "error", 2)
+	assert.True(t, errors.Is(err, eventbus.ErrBufferFull), err)
+	assert.Equal(t, []interface{}{1}, buffered(ec))
+}
+
+func TestEventBus_PolicyBlockCanceled(t *testing.T) {
+	eb := eventbus.NewEventBus()
+	_ = eb.SubscribeEvent("block")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := eb.PublishEventContext(ctx, "block", nil)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), err)
+}
+
+func TestEventBus_ConcurrentDelivery(t *testing.T) {
+	eb := eventbus.NewEventBus()
+	_ = eb.SubscribeEvent("concurrent") // never receives
+	fast := eb.SubscribeEvent("concurrent")
+
+	received := make(chan time.Time, 1)
+	go func() {
+		event := <-fast
+		received <- time.Now()
+		event.Done()
+	}()
+
+	start := time.Now()
+	assert.NoError(t, eb.PublishEventAsyncContext(context.Background(), "concurrent", nil))
+
+	// The fast subscriber was not held up by the slow one.
+	assert.Less(t, (<-received).Sub(start), 100*time.Millisecond)
+}
+
+func TestEventBus_AsyncStalledSubscriber(t *testing.T) {
+	eb := eventbus.NewEventBus()
+	stalled := eb.SubscribeEvent("stalled")
+
+	published := make(chan struct{})
+	go func() {
+		for i := 1; i <= 3; i++ {
+			eb.PublishEventAsync("stalled", i)
+		}
+		close(published)
+	}()
+
+	select {
+	case <-published:
+	case <-time.After(time.Second):
+		t.Fatal("PublishEventAsync blocked on a stalled subscriber")
+	}
+
+	// The queued Events are delivered in order once the subscriber reads.
+	for i := 1; i <= 3; i++ {
+		event := <-stalled
+		assert.Equal(t, i, event.Data)
+		event.Done()
+	}
+}
+
+func TestEventBus_SlowSubscriber(t *testing.T) {
+	eb := eventbus.NewEventBus()
+	ec := eb.SubscribeEventWithOptions("slow", eventbus.SubscribeOptions{LagThreshold: 10 * time.Millisecond})
+	slow := metrics.SlowSubscribers.WithLabelValues("slow")
+	before := testutil.ToFloat64(slow)
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		event := <-ec
+		event.Done()
+	}()
+	eb.PublishEvent("slow", nil)
+
+	assert.Equal(t, before+1, testutil.ToFloat64(slow))
+}
+
+func TestEventBus_SubscribeInvalidPolicy(t *testing.T) {
+	eb := eventbus.NewEventBus()
+	_ = eb.SubscribeEventWithOptions("foo", eventbus.SubscribeOptions{Policy: "drop-all"})
+
+	assert.False(t, eb.HasSubscribers("foo"))
+}
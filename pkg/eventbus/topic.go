@@ -0,0 +1,77 @@
+//
+// Copyright (c) 2024 Quadient Group AG
+//
+// This file is subject to the terms and conditions defined in the
+// 'LICENSE' file found in the root of this source code package.
+//
+
+package eventbus
+
+import (
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Event names are topics whose levels are separated by dots, such as
+// "init.deploy.workflows"; flat names like "init-start" are topics with
+// a single level. Subscriptions may name a topic pattern instead:
+//
+//   - within a level, * matches any sequence of characters and ? any
+//     single character (as in path.Match), so "init-*" matches all
+//     events of demo init and "init.deploy.*" matches
+//     "init.deploy.workflows" but not "init.deploy.workflows.retry"
+//   - a ** level matches any number of levels, so "init.**" matches
+//     "init" and every topic below it.
+const topicSeparator = "."
+
+// multiLevelWildcard is the pattern level matching any number of levels.
+const multiLevelWildcard = "**"
+
+// IsPattern returns true if name is a topic pattern rather than the
+// name of an Event.
+func IsPattern(name string) bool {
+	return strings.ContainsAny(name, `*?[\`)
+}
+
+// ValidatePattern returns an error if the topic pattern is malformed.
+func ValidatePattern(pattern string) error {
+	for _, level := range strings.Split(pattern, topicSeparator) {
+		if level == multiLevelWildcard {
+			continue
+		}
+		if _, err := path.Match(level, ""); err != nil {
+			return errors.Wrapf(err, "invalid topic pattern %q", pattern)
+		}
+	}
+	return nil
+}
+
+// Match returns true if the Event name matches the topic pattern.
+// Malformed patterns match nothing.
+func Match(pattern string, name string) bool {
+	return matchLevels(strings.Split(pattern, topicSeparator), strings.Split(name, topicSeparator))
+}
+
+// matchLevels matches the levels of a topic against those of a pattern.
+func matchLevels(pattern []string, topic []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == multiLevelWildcard {
+			for i := 0; i <= len(topic); i++ {
+				if matchLevels(pattern[1:], topic[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(topic) == 0 {
+			return false
+		}
+		if matched, err := path.Match(pattern[0], topic[0]); err != nil || !matched {
+			return false
+		}
+		pattern, topic = pattern[1:], topic[1:]
+	}
+	return len(topic) == 0
+}
@@ -0,0 +1,91 @@
+//
+// Copyright (c) 2024 Quadient Group AG
+//
+// This file is subject to the terms and conditions defined in the
+// 'LICENSE' file found in the root of this source code package.
+//
+
+package eventbus_test
+
+import (
+	"testing"
+
+	"github.com/robertwtucker/spt-util/pkg/eventbus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{"init-start", "init-start", true},
+		{"init-*", "init-start", true},
+		{"init-*", "init-deploy-scaler-workflows", true},
+		{"init-*", "job-log", false},
+		{"job-???", "job-log", true},
+		{"*", "init-start", true},
+		{"*", "init.start", false},
+		{"init.deploy.*", "init.deploy.workflows", true},
+		{"init.deploy.*", "init.deploy", false},
+		{"init.deploy.*", "init.deploy.workflows.retry", false},
+		{"init.*.workflows", "init.find.workflows", true},
+		{"init.**", "init", true},
+		{"init.**", "init.deploy.workflows.retry", true},
+		{"init.**", "initial.start", false},
+		{"**.retry", "init.deploy.workflows.retry", true},
+		{"**", "anything.at.all", true},
+		{"init-[", "init-[", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, eventbus.Match(tt.pattern, tt.name), "%s ~ %s", tt.pattern, tt.name)
+	}
+}
+
+func TestValidatePattern(t *testing.T) {
+	assert.NoError(t, eventbus.ValidatePattern("init.**.[a-z]*"))
+	assert.Error(t, eventbus.ValidatePattern("init-["))
+}
+
+func TestEventBus_SubscribePattern(t *testing.T) {
+	eb := eventbus.NewEventBus()
+	all := eb.SubscribeEvent("init-*")
+	start := eb.SubscribeEvent(eventbus.InitStart)
+	// A channel subscribed more than once receives an Event once.
+	eb.SubscribeEventChannel(all, eventbus.InitStart)
+
+	received := make(chan string, 4)
+	for _, ec := range []eventbus.EventChannel{all, start} {
+		go func(ec eventbus.EventChannel) {
+			for event := range ec {
+				received <- event.Name
+				event.Done()
+			}
+		}(ec)
+	}
+
+	assert.True(t, eb.HasSubscribers("init-*"))
+	assert.True(t, eb.HasSubscribers(eventbus.InitFindScalerWorkflows))
+	assert.False(t, eb.HasSubscribers(eventbus.JobLog))
+
+	eb.PublishEvent(eventbus.InitStart, nil)
+	eb.PublishEvent(eventbus.InitFindScalerWorkflows, nil)
+	eb.PublishEvent(eventbus.JobLog, nil)
+	close(received)
+
+	var names []string
+	for name := range received {
+		names = append(names, name)
+	}
+	assert.ElementsMatch(t, []string{
+		eventbus.InitStart, eventbus.InitStart, eventbus.InitFindScalerWorkflows,
+	}, names)
+}
+
+func TestEventBus_SubscribeInvalidPattern(t *testing.T) {
+	eb := eventbus.NewEventBus()
+	_ = eb.SubscribeEvent("init-[")
+
+	assert.False(t, eb.HasSubscribers("init-["))
+}
@@ -0,0 +1,221 @@
+//
+// Copyright (c) 2024 Quadient Group AG
+//
+// This file is subject to the terms and conditions defined in the
+// 'LICENSE' file found in the root of this source code package.
+//
+
+// Package kube discovers the settings of an Inspire deployment from
+// the Kubernetes resources of its Helm release.
+package kube
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// Labels identifying the resources of a Helm release.
+const (
+	InstanceLabel       = "app.kubernetes.io/instance"
+	LegacyReleaseLabel  = "release"
+	scalerComponentName = "scaler"
+)
+
+// Keys looked up in the Scaler Secret and ConfigMap.
+var (
+	UsernameKeys = []string{"username", "user", "SCALER_USER"}
+	PasswordKeys = []string{"password", "pass", "SCALER_PASS"}
+	URLKeys      = []string{"url", "scalerUrl", "SCALER_URL"}
+)
+
+// Scaler holds the connection settings discovered for a Scaler
+// instance. Values that could not be discovered are empty.
+type Scaler struct {
+	URL      string
+	Username string
+	Password string
+}
+
+// NewClient creates a Kubernetes client from the kubeconfig file (or
+// the default loading rules, e.g. $KUBECONFIG, if empty) and context,
+// falling back to the in-cluster configuration.
+func NewClient(kubeconfig string, kubeContext string) (kubernetes.Interface, error) {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if kubeconfig != "" {
+		rules.ExplicitPath = kubeconfig
+	}
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: kubeContext}
+	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides).ClientConfig()
+	if err != nil {
+		return nil, errors.Wrap(err, "error loading Kubernetes client configuration")
+	}
+
+	client, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating Kubernetes client")
+	}
+	return client, nil
+}
+
+// DiscoverScaler looks up the Scaler Service, Secret and ConfigMap of
+// the Helm release in the namespace. The URL is taken from the
+// ConfigMap, if it provides one, or derived from the Service; the
+// credentials are read from the Secret. Only the resources labeled with
+// the release are listed.
+func DiscoverScaler(
+	ctx context.Context, client kubernetes.Interface, namespace string, release string,
+) (*Scaler, error) {
+	scaler := &Scaler{}
+
+	services, err := list(ctx, release, client.CoreV1().Services(namespace).List)
+	if err != nil {
+		return nil, errors.Wrap(err, "error listing services")
+	}
+	if service, ok := find(release, services, nil).(*corev1.Service); ok {
+		scaler.URL = serviceURL(service)
+	}
+
+	configMaps, err := list(ctx, release, client.CoreV1().ConfigMaps(namespace).List)
+	if err != nil {
+		return nil, errors.Wrap(err, "error listing config maps")
+	}
+	if configMap, ok := find(release, configMaps, nil).(*corev1.ConfigMap); ok {
+		if url := lookup(configMap.Data, URLKeys); url != "" {
+			scaler.URL = url
+		}
+	}
+
+	secrets, err := list(ctx, release, client.CoreV1().Secrets(namespace).List)
+	if err != nil {
+		return nil, errors.Wrap(err, "error listing secrets")
+	}
+	// Other Secrets of Scaler, e.g. TLS certificates, hold no credentials.
+	if secret, ok := find(release, secrets, func(object metav1.Object) bool {
+		secret, ok := object.(*corev1.Secret)
+		if !ok {
+			return false
+		}
+		data := secretData(secret)
+		return lookup(data, UsernameKeys) != "" || lookup(data, PasswordKeys) != ""
+	}).(*corev1.Secret); ok {
+		data := secretData(secret)
+		scaler.Username = lookup(data, UsernameKeys)
+		scaler.Password = lookup(data, PasswordKeys)
+	}
+
+	if scaler.URL == "" {
+		return scaler, errors.Errorf("no Scaler service found for release %q in namespace %q", release, namespace)
+	}
+	log.WithFields(log.Fields{
+		"namespace": namespace,
+		"release":   release,
+		"url":       scaler.URL,
+	}).Debug("discovered Scaler")
+	return scaler, nil
+}
+
+// list returns the resources labeled with the release's instance label
+// or, for older charts, its legacy release label, using the List method
+// of a typed client.
+func list[L runtime.Object](
+	ctx context.Context, release string, lister func(context.Context, metav1.ListOptions) (L, error),
+) ([]metav1.Object, error) {
+	objects := []metav1.Object{}
+	seen := map[string]bool{}
+	for _, label := range []string{InstanceLabel, LegacyReleaseLabel} {
+		result, err := lister(ctx, metav1.ListOptions{LabelSelector: label + "=" + release})
+		if err != nil {
+			return nil, err
+		}
+		items, err := meta.ExtractList(result)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			object, err := meta.Accessor(item)
+			if err != nil {
+				return nil, err
+			}
+			if !seen[object.GetName()] {
+				seen[object.GetName()] = true
+				objects = append(objects, object)
+			}
+		}
+	}
+	return objects, nil
+}
+
+// find returns the release's Scaler resource among the objects or nil.
+// The resource named <release>-scaler is preferred, otherwise the first
+// one with a name containing "scaler" is returned. If accept is not nil,
+// only the resources it accepts are considered.
+func find(release string, objects []metav1.Object, accept func(object metav1.Object) bool) metav1.Object {
+	preferred := release + "-" + scalerComponentName
+	var found metav1.Object
+	for _, object := range objects {
+		labels := object.GetLabels()
+		if labels[InstanceLabel] != release && labels[LegacyReleaseLabel] != release {
+			continue
+		}
+		if accept != nil && !accept(object) {
+			continue
+		}
+		name := object.GetName()
+		if name == preferred {
+			return object
+		}
+		if found == nil && strings.Contains(strings.ToLower(name), scalerComponentName) {
+			found = object
+		}
+	}
+	return found
+}
+
+// secretData returns the decoded values of a Secret.
+func secretData(secret *corev1.Secret) map[string]string {
+	data := map[string]string{}
+	for key, value := range secret.Data {
+		data[key] = string(value)
+	}
+	return data
+}
+
+// serviceURL returns the in-cluster URL of a Service's HTTP(S) port.
+func serviceURL(service *corev1.Service) string {
+	if len(service.Spec.Ports) == 0 {
+		return ""
+	}
+	port := service.Spec.Ports[0]
+	for _, p := range service.Spec.Ports {
+		if strings.HasPrefix(strings.ToLower(p.Name), "http") {
+			port = p
+			break
+		}
+	}
+
+	scheme := "http"
+	if strings.EqualFold(port.Name, "https") || port.Port == 443 || port.Port == 8443 {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s.%s.svc:%d", scheme, service.Name, service.Namespace, port.Port)
+}
+
+// lookup returns the first non-empty value of the keys in data.
+func lookup(data map[string]string, keys []string) string {
+	for _, key := range keys {
+		if value := strings.TrimSpace(data[key]); value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,166 @@
+//
+// Copyright (c) 2024 Quadient Group AG
+//
+// This file is subject to the terms and conditions defined in the
+// 'LICENSE' file found in the root of this source code package.
+//
+
+package kube_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/robertwtucker/spt-util/pkg/kube"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// meta returns the metadata of a resource of the given release.
+func meta(name string, release string) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:      name,
+		Namespace: "demo",
+		Labels:    map[string]string{kube.InstanceLabel: release},
+	}
+}
+
+func TestDiscoverScaler(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		&corev1.Service{
+			ObjectMeta: meta("inspire-scaler", "inspire"),
+			Spec: corev1.ServiceSpec{Ports: []corev1.ServicePort{
+				{Name: "metrics", Port: 9090},
+				{Name: "http", Port: 30600},
+			}},
+		},
+		&corev1.Service{
+			ObjectMeta: meta("other-scaler", "other"),
+			Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Name: "http", Port: 80}}},
+		},
+		&corev1.Secret{
+			ObjectMeta: meta("inspire-scaler-credentials", "inspire"),
+			Data: map[string][]byte{
+				"username": []byte("admin"),
+				"password": []byte("s3cret\n"),
+			},
+		},
+	)
+
+	scaler, err := kube.DiscoverScaler(context.Background(), client, "demo", "inspire")
+	require.NoError(t, err)
+	assert.Equal(t, &kube.Scaler{
+		URL:      "http://inspire-scaler.demo.svc:30600",
+		Username: "admin",
+		Password: "s3cret",
+	}, scaler)
+}
+
+func TestDiscoverScaler_ConfigMapURL(t *testing.T) {
+	legacy := meta("scaler", "")
+	legacy.Labels = map[string]string{kube.LegacyReleaseLabel: "inspire"}
+	client := fake.NewSimpleClientset(
+		&corev1.Service{
+			ObjectMeta: legacy,
+			Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Name: "https", Port: 8443}}},
+		},
+		&corev1.ConfigMap{
+			ObjectMeta: meta("inspire-scaler-config", "inspire"),
+			Data:       map[string]string{"SCALER_URL": "https://scaler.example.com"},
+		},
+	)
+
+	scaler, err := kube.DiscoverScaler(context.Background(), client, "demo", "inspire")
+	require.NoError(t, err)
+	assert.Equal(t, "https://scaler.example.com", scaler.URL)
+	assert.Empty(t, scaler.Username)
+}
+
+func TestDiscoverScaler_NotFound(t *testing.T) {
+	client := fake.NewSimpleClientset(&corev1.Service{
+		ObjectMeta: meta("inspire-scaler", "inspire"),
+		Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Name: "http", Port: 80}}},
+	})
+
+	_, err := kube.DiscoverScaler(context.Background(), client, "other-namespace", "inspire")
+	assert.Error(t, err)
+}
+
+func TestDiscoverScaler_IgnoresOtherSecrets(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		&corev1.Service{
+			ObjectMeta: meta("inspire-scaler", "inspire"),
+			Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Name: "http", Port: 30600}}},
+		},
+		&corev1.Secret{
+			ObjectMeta: meta("inspire-scaler-tls", "inspire"),
+			Data: map[string][]byte{
+				"tls.crt": []byte("certificate"),
+				"tls.key": []byte("key"),
+			},
+		},
+		&corev1.Secret{
+			ObjectMeta: meta("inspire-scaler-credentials", "other"),
+			Data:       map[string][]byte{"username": []byte("intruder")},
+		},
+		&corev1.Secret{
+			ObjectMeta: meta("inspire-scaler-login", "inspire"),
+			Data: map[string][]byte{
+				"SCALER_USER": []byte("admin"),
+				"SCALER_PASS": []byte("s3cret"),
+			},
+		},
+	)
+
+	scaler, err := kube.DiscoverScaler(context.Background(), client, "demo", "inspire")
+	require.NoError(t, err)
+	assert.Equal(t, "admin", scaler.Username)
+	assert.Equal(t, "s3cret", scaler.Password)
+}
+
+func TestDiscoverScaler_PreferredSecretWithoutCredentials(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		&corev1.Service{
+			ObjectMeta: meta("inspire-scaler", "inspire"),
+			Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Name: "http", Port: 30600}}},
+		},
+		// The Secret named after the release holds a certificate only.
+		&corev1.Secret{
+			ObjectMeta: meta("inspire-scaler", "inspire"),
+			Data:       map[string][]byte{"tls.crt": []byte("certificate")},
+		},
+		&corev1.Secret{
+			ObjectMeta: meta("inspire-scaler-login", "inspire"),
+			Data: map[string][]byte{
+				"username": []byte("admin"),
+				"password": []byte("s3cret"),
+			},
+		},
+	)
+
+	scaler, err := kube.DiscoverScaler(context.Background(), client, "demo", "inspire")
+	require.NoError(t, err)
+	assert.Equal(t, "admin", scaler.Username)
+	assert.Equal(t, "s3cret", scaler.Password)
+}
+
+func TestDiscoverScaler_LabelSelectors(t *testing.T) {
+	client := fake.NewSimpleClientset()
+
+	_, _ = kube.DiscoverScaler(context.Background(), client, "demo", "inspire")
+
+	selectors := map[string]bool{}
+	for _, action := range client.Actions() {
+		if list, ok := action.(k8stesting.ListAction); ok {
+			selectors[list.GetListRestrictions().Labels.String()] = true
+		}
+	}
+	assert.Equal(t, map[string]bool{
+		kube.InstanceLabel + "=inspire":      true,
+		kube.LegacyReleaseLabel + "=inspire": true,
+	}, selectors)
+}
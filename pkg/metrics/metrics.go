@@ -0,0 +1,193 @@
+//
+// Copyright (c) 2024 Quadient Group AG
+//
+// This file is subject to the terms and conditions defined in the
+// 'LICENSE' file found in the root of this source code package.
+//
+
+// Package metrics defines the Prometheus metrics of demo operations,
+// served on /metrics in serve mode and pushed to a Pushgateway after
+// one-shot command runs.
+package metrics
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/client_golang/prometheus/push"
+	"github.com/robertwtucker/spt-util/pkg/report"
+)
+
+// namespace prefixes the metric names.
+const namespace = "spt"
+
+// Registry holds the application metrics.
+var Registry = prometheus.NewRegistry()
+
+// Application metrics.
+var (
+	ScalerRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "scaler_requests_total",
+		Help:      "Scaler requests by endpoint, method and HTTP status (\"error\" if none was received).",
+	}, []string{"endpoint", "method", "status"})
+
+	ScalerRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "scaler_request_duration_seconds",
+		Help:      "Latency of Scaler requests by endpoint and method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"endpoint", "method"})
+
+	StepDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "step_duration_seconds",
+		Help:      "Duration of the steps of demo operations by command, step and outcome.",
+		Buckets:   []float64{0.1, 0.5, 1, 5, 15, 30, 60, 120, 300, 600},
+	}, []string{"command", "step", "outcome"})
+
+	WorkflowsDeployed = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "workflows_deployed_total",
+		Help:      "Scaler workflows deployed.",
+	})
+
+	BytesStaged = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "staged_bytes_total",
+		Help:      "Bytes written by staging.",
+	})
+
+	BytesDownloaded = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "downloaded_bytes_total",
+		Help:      "Bytes downloaded from remote sources by staging.",
+	})
+
+	EventsPublished = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "eventbus_published_total",
+		Help:      "Events published on the event bus by event name.",
+	}, []string{"event"})
+
+	EventSubscriptions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "eventbus_subscriptions_total",
+		Help:      "Event bus subscriptions by event name.",
+	}, []string{"event"})
+
+	EventsDropped = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "eventbus_dropped_total",
+		Help:      "Events not delivered to a subscriber by event name and reason (full buffer policy or \"canceled\").",
+	}, []string{"event", "reason"})
+
+	SlowSubscribers = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "eventbus_slow_deliveries_total",
+		Help:      "Events a subscriber did not accept within its lag threshold by event name.",
+	}, []string{"event"})
+)
+
+//nolint:gochecknoinits // the metrics are registered once.
+func init() {
+	Registry.MustRegister(
+		ScalerRequests, ScalerRequestDuration, StepDuration,
+		WorkflowsDeployed, BytesStaged, BytesDownloaded, EventsPublished, EventSubscriptions,
+		EventsDropped, SlowSubscribers,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// Handler serves the metrics in the Prometheus exposition format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+// Push replaces the metrics of the job and grouping labels on the
+// Pushgateway at url.
+func Push(url string, job string, grouping map[string]string) error {
+	pusher := push.New(url, job).Gatherer(Registry)
+	for name, value := range grouping {
+		if value != "" {
+			pusher = pusher.Grouping(name, value)
+		}
+	}
+	if err := pusher.Push(); err != nil {
+		return errors.Wrap(err, "error pushing metrics")
+	}
+	return nil
+}
+
+// ObserveReport records the durations of the finished steps of a
+// report.
+func ObserveReport(rpt *report.Report) {
+	for _, step := range rpt.Snapshot().Steps {
+		ObserveStep(rpt.Command, step)
+	}
+}
+
+// ObserveStep records the duration of a finished report step. Step
+// names are reduced to their first word (e.g. "stage" for "stage
+// /path/to/source") to keep the number of series small.
+func ObserveStep(command string, step *report.Step) {
+	if step.Outcome == report.OutcomeRunning {
+		return
+	}
+	name := strings.Fields(step.Name + " ")[0]
+	StepDuration.WithLabelValues(command, name, string(step.Outcome)).Observe(step.Duration)
+}
+
+// idSegment matches path segments that identify a resource, such as
+// numbers, UUIDs and hexadecimal IDs.
+var idSegment = regexp.MustCompile(`^([0-9]+|[0-9a-fA-F-]{16,})$`)
+
+// Endpoint returns the path of a request URL with resource IDs replaced
+// by {id}, for use as a metric label.
+func Endpoint(path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i, segment := range segments {
+		if idSegment.MatchString(segment) {
+			segments[i] = "{id}"
+		}
+	}
+	return "/" + strings.Join(segments, "/")
+}
+
+// Transport returns an http.RoundTripper recording the count and
+// latency of the Scaler requests made through next (or the default
+// transport, if nil).
+func Transport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return roundTripper{next: next}
+}
+
+// roundTripper records Scaler request metrics.
+type roundTripper struct {
+	next http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t roundTripper) RoundTrip(request *http.Request) (*http.Response, error) {
+	endpoint := Endpoint(request.URL.Path)
+	start := time.Now()
+	response, err := t.next.RoundTrip(request)
+	ScalerRequestDuration.WithLabelValues(endpoint, request.Method).Observe(time.Since(start).Seconds())
+
+	status := "error"
+	if err == nil {
+		status = strconv.Itoa(response.StatusCode)
+	}
+	ScalerRequests.WithLabelValues(endpoint, request.Method, status).Inc()
+	return response, err
+}
@@ -0,0 +1,101 @@
+//
+// Copyright (c) 2024 Quadient Group AG
+//
+// This file is subject to the terms and conditions defined in the
+// 'LICENSE' file found in the root of this source code package.
+//
+
+package metrics_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/robertwtucker/spt-util/pkg/metrics"
+	"github.com/robertwtucker/spt-util/pkg/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEndpoint(t *testing.T) {
+	assert.Equal(t, "/api/integration/v2/workflows", metrics.Endpoint("/api/integration/v2/workflows/"))
+	assert.Equal(t, "/api/integration/v2/workflows/{id}", metrics.Endpoint("/api/integration/v2/workflows/42"))
+	assert.Equal(t, "/api/jobs/{id}/status",
+		metrics.Endpoint("/api/jobs/0f8fad5b-d9cb-469f-a165-70867728950e/status"))
+}
+
+func TestTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: metrics.Transport(nil)}
+	counter := metrics.ScalerRequests.WithLabelValues("/api/version", http.MethodGet, "418")
+	before := testutil.ToFloat64(counter)
+	response, err := client.Get(server.URL + "/api/version")
+	require.NoError(t, err)
+	_ = response.Body.Close()
+
+	assert.Equal(t, before+1, testutil.ToFloat64(counter))
+}
+
+func TestObserveReport(t *testing.T) {
+	rpt := report.New("demo test")
+	rpt.StartStep("stage /some/source").Finish(nil)
+	rpt.StartStep("import").Finish(errors.New("boom"))
+	rpt.Finish()
+	metrics.ObserveReport(rpt)
+
+	count, err := testutil.GatherAndCount(metrics.Registry, "spt_step_duration_seconds")
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	families, err := metrics.Registry.Gather()
+	require.NoError(t, err)
+	var steps []string
+	for _, family := range families {
+		if family.GetName() != "spt_step_duration_seconds" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "step" {
+					steps = append(steps, label.GetValue())
+				}
+			}
+		}
+	}
+	assert.ElementsMatch(t, []string{"stage", "import"}, steps)
+}
+
+func TestPush(t *testing.T) {
+	var path, body string
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPut, r.Method)
+		path = r.URL.Path
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer gateway.Close()
+
+	metrics.BytesStaged.Add(1024)
+	err := metrics.Push(gateway.URL, "spt-util", map[string]string{"namespace": "demo", "release": ""})
+	require.NoError(t, err)
+	assert.Equal(t, "/metrics/job/spt-util/namespace/demo", path)
+	assert.NotEmpty(t, body)
+}
+
+func TestPushFailure(t *testing.T) {
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer gateway.Close()
+
+	assert.Error(t, metrics.Push(gateway.URL, "spt-util", nil))
+}
@@ -0,0 +1,217 @@
+//
+// Copyright (c) 2024 Quadient Group AG
+//
+// This file is subject to the terms and conditions defined in the
+// 'LICENSE' file found in the root of this source code package.
+//
+
+// Package redact removes sensitive values from log entries.
+package redact
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/robertwtucker/spt-util/pkg/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// maxDepth limits the traversal of nested (possibly cyclic) values.
+const maxDepth = 16
+
+// DefaultPatterns match secrets embedded in strings: credentials in
+// authorization headers, key/value pairs such as password=... and the
+// user info of URLs.
+var DefaultPatterns = []string{
+	`(?i)\b(basic|bearer)\s+[A-Za-z0-9+/=._~-]{8,}`,
+	`(?i)((?:password|passwd|secret|token|api[_-]?key)["']?\s*[:=]\s*["']?)[^\s"'&,;]+`,
+	`(://[^:/@\s]+:)[^@/\s]+@`,
+}
+
+// replacements are the substitutions for DefaultPatterns.
+var replacements = []string{
+	"$1 " + config.Redacted,
+	"${1}" + config.Redacted,
+	"${1}" + config.Redacted + "@",
+}
+
+// Hook is a logrus hook that redacts the fields of log entries whose
+// names denote secrets, including fields of nested structs and maps,
+// and secrets matching its patterns in messages and string values.
+type Hook struct {
+	fields   map[string]bool
+	patterns []*regexp.Regexp
+	replaces []string
+}
+
+// NewHook creates a Hook redacting fields whose names are known secret
+// keys or one of the given names (case-insensitive) and strings
+// matching the default or the given patterns.
+func NewHook(fields []string, patterns []string) (*Hook, error) {
+	hook := &Hook{fields: map[string]bool{}}
+	for _, field := range fields {
+		hook.fields[strings.ToLower(field)] = true
+	}
+
+	for i, pattern := range append(append([]string{}, DefaultPatterns...), patterns...) {
+		expr, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid redaction pattern %q", pattern)
+		}
+		replace := config.Redacted
+		if i < len(replacements) {
+			replace = replacements[i]
+		}
+		hook.patterns = append(hook.patterns, expr)
+		hook.replaces = append(hook.replaces, replace)
+	}
+	return hook, nil
+}
+
+// Levels implements logrus.Hook.
+func (h *Hook) Levels() []log.Level {
+	return log.AllLevels
+}
+
+// Fire implements logrus.Hook.
+func (h *Hook) Fire(entry *log.Entry) error {
+	entry.Message = h.String(entry.Message)
+	for key, value := range entry.Data {
+		entry.Data[key] = h.field(key, value)
+	}
+	return nil
+}
+
+// String redacts the secrets matching the hook's patterns in s.
+func (h *Hook) String(s string) string {
+	for i, expr := range h.patterns {
+		s = expr.ReplaceAllString(s, h.replaces[i])
+	}
+	return s
+}
+
+// Value returns a copy of v with its secrets redacted. Values of other
+// types are returned as they are.
+func (h *Hook) Value(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	if err, ok := v.(error); ok {
+		if redacted := h.String(err.Error()); redacted != err.Error() {
+			return redacted
+		}
+		return v
+	}
+	return h.value(reflect.ValueOf(v), 0).Interface()
+}
+
+// secret reports whether a field or key name denotes a secret.
+func (h *Hook) secret(name string) bool {
+	return h.fields[strings.ToLower(name)] || config.IsSecret(name)
+}
+
+// field redacts a named value.
+func (h *Hook) field(name string, v interface{}) interface{} {
+	if h.secret(name) {
+		return config.Redacted
+	}
+	return h.Value(v)
+}
+
+// value returns a redacted copy of v.
+func (h *Hook) value(v reflect.Value, depth int) reflect.Value {
+	if depth > maxDepth {
+		return v
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		redacted := reflect.New(v.Type()).Elem()
+		redacted.SetString(h.String(v.String()))
+		return redacted
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		redacted := reflect.New(v.Type().Elem())
+		redacted.Elem().Set(h.value(v.Elem(), depth+1))
+		return redacted
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		redacted := reflect.New(v.Type()).Elem()
+		redacted.Set(h.value(v.Elem(), depth+1))
+		return redacted
+	case reflect.Struct:
+		redacted := reflect.New(v.Type()).Elem()
+		redacted.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			target := redacted.Field(i)
+			if h.secret(field.Name) || h.secret(jsonName(field)) {
+				if target.Kind() == reflect.String {
+					target.SetString(config.Redacted)
+				} else {
+					target.Set(reflect.Zero(field.Type))
+				}
+				continue
+			}
+			target.Set(h.value(v.Field(i), depth+1))
+		}
+		return redacted
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		redacted := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			value := iter.Value()
+			if key := iter.Key(); key.Kind() == reflect.String && h.secret(key.String()) {
+				value = secretValue(value)
+			} else {
+				value = h.value(value, depth+1)
+			}
+			redacted.SetMapIndex(iter.Key(), value)
+		}
+		return redacted
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		redacted := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			redacted.Index(i).Set(h.value(v.Index(i), depth+1))
+		}
+		return redacted
+	default:
+		return v
+	}
+}
+
+// secretValue returns the replacement for a secret map value.
+func secretValue(v reflect.Value) reflect.Value {
+	redacted := reflect.ValueOf(config.Redacted)
+	if redacted.Type().AssignableTo(v.Type()) {
+		return redacted
+	}
+	if v.Kind() == reflect.String {
+		return redacted.Convert(v.Type())
+	}
+	return reflect.Zero(v.Type())
+}
+
+// jsonName returns the name of a struct field in JSON.
+func jsonName(field reflect.StructField) string {
+	name := strings.Split(field.Tag.Get("json"), ",")[0]
+	if name == "" || name == "-" {
+		return field.Name
+	}
+	return name
+}
@@ -0,0 +1,94 @@
+//
+// Copyright (c) 2024 Quadient Group AG
+//
+// This file is subject to the terms and conditions defined in the
+// 'LICENSE' file found in the root of this source code package.
+//
+
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// junitTestSuites is the root element of a JUnit XML report.
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+// junitTestSuite maps a Report onto a JUnit test suite.
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+// junitTestCase maps a Step onto a JUnit test case.
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+// junitMessage holds the message of a failed or skipped test case.
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+}
+
+// toJUnit converts the Report into its JUnit XML representation.
+func (r *Report) toJUnit() junitTestSuites {
+	suite := junitTestSuite{
+		Name:      r.Command,
+		Tests:     len(r.Steps),
+		Time:      fmt.Sprintf("%.3f", r.Duration),
+		Timestamp: r.StartTime.Format(time.RFC3339),
+		TestCases: []junitTestCase{},
+	}
+
+	for _, step := range r.Steps {
+		testCase := junitTestCase{
+			Name:      step.Name,
+			ClassName: r.Command,
+			Time:      fmt.Sprintf("%.3f", step.Duration),
+			SystemOut: step.details(),
+		}
+		switch step.Outcome {
+		case OutcomeFailure, OutcomeRunning:
+			suite.Failures++
+			testCase.Failure = &junitMessage{Message: step.Error}
+		case OutcomeSkipped:
+			suite.Skipped++
+			testCase.Skipped = &junitMessage{Message: step.Message}
+		case OutcomeSuccess:
+		}
+		suite.TestCases = append(suite.TestCases, testCase)
+	}
+
+	return junitTestSuites{Suites: []junitTestSuite{suite}}
+}
+
+// details summarizes the data collected by a Step for JUnit output.
+func (s *Step) details() string {
+	lines := []string{}
+	if len(s.HTTPStatusCodes) > 0 {
+		lines = append(lines, fmt.Sprintf("http status codes: %v", s.HTTPStatusCodes))
+	}
+	if len(s.Workflows) > 0 {
+		lines = append(lines, "workflows: "+strings.Join(s.Workflows, ", "))
+	}
+	if len(s.Files) > 0 {
+		lines = append(lines, "files: "+strings.Join(s.Files, ", "))
+	}
+	return strings.Join(lines, "\n")
+}
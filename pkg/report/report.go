@@ -0,0 +1,299 @@
+//
+// Copyright (c) 2024 Quadient Group AG
+//
+// This file is subject to the terms and conditions defined in the
+// 'LICENSE' file found in the root of this source code package.
+//
+
+package report
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Outcome describes the result of a run or one of its steps.
+type Outcome string
+
+// Outcomes recorded for a run and its steps.
+const (
+	OutcomeRunning Outcome = "running"
+	OutcomeSuccess Outcome = "success"
+	OutcomeFailure Outcome = "failure"
+	OutcomeSkipped Outcome = "skipped"
+)
+
+// Report formats supported by Write.
+const (
+	FormatJSON  = "json"
+	FormatJUnit = "junit"
+)
+
+// Report is a machine-readable record of a command run.
+type Report struct {
+	Command   string    `json:"command"`
+	StartTime time.Time `json:"startTime"`
+	EndTime   time.Time `json:"endTime"`
+	Duration  float64   `json:"durationSeconds"`
+	Outcome   Outcome   `json:"outcome"`
+	Steps     []*Step   `json:"steps"`
+	mutex     sync.Mutex
+	observers []Observer
+}
+
+// Observer is notified when a step of a Report starts or ends. It
+// receives a snapshot of the step that it may keep.
+type Observer func(step *Step)
+
+// Step is a single unit of work within a Report.
+type Step struct {
+	Name            string    `json:"name"`
+	StartTime       time.Time `json:"startTime"`
+	EndTime         time.Time `json:"endTime"`
+	Duration        float64   `json:"durationSeconds"`
+	Outcome         Outcome   `json:"outcome"`
+	Error           string    `json:"error,omitempty"`
+	Message         string    `json:"message,omitempty"`
+	HTTPStatusCodes []int     `json:"httpStatusCodes,omitempty"`
+	Workflows       []string  `json:"workflows,omitempty"`
+	Files           []string  `json:"files,omitempty"`
+	mutex           sync.Mutex
+	report          *Report
+}
+
+// New creates a Report for the named command.
+func New(command string) *Report {
+	return &Report{
+		Command:   command,
+		StartTime: time.Now(),
+		Outcome:   OutcomeRunning,
+		Steps:     []*Step{},
+	}
+}
+
+// StartStep adds a new, running Step to the Report.
+func (r *Report) StartStep(name string) *Step {
+	step := &Step{
+		Name:      name,
+		StartTime: time.Now(),
+		Outcome:   OutcomeRunning,
+		report:    r,
+	}
+
+	r.mutex.Lock()
+	r.Steps = append(r.Steps, step)
+	r.mutex.Unlock()
+
+	r.notify(step)
+	return step
+}
+
+// Observe registers an observer notified when each step starts and
+// when it ends.
+func (r *Report) Observe(observer Observer) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.observers = append(r.observers, observer)
+}
+
+// notify passes a snapshot of the step to the observers.
+func (r *Report) notify(step *Step) {
+	r.mutex.Lock()
+	observers := append([]Observer{}, r.observers...)
+	r.mutex.Unlock()
+	if len(observers) == 0 {
+		return
+	}
+
+	snapshot := step.Snapshot()
+	for _, observer := range observers {
+		observer(&snapshot)
+	}
+}
+
+// Snapshot returns a copy of the Report and its steps, which can be
+// read and encoded while the steps are still being recorded.
+func (r *Report) Snapshot() *Report {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	snapshot := &Report{
+		Command:   r.Command,
+		StartTime: r.StartTime,
+		EndTime:   r.EndTime,
+		Duration:  r.Duration,
+		Outcome:   r.Outcome,
+		Steps:     make([]*Step, 0, len(r.Steps)),
+	}
+	for _, step := range r.Steps {
+		stepSnapshot := step.Snapshot()
+		snapshot.Steps = append(snapshot.Steps, &stepSnapshot)
+	}
+	return snapshot
+}
+
+// Finish marks the end of the run. The Report fails if any of its
+// steps failed or were left running.
+func (r *Report) Finish() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.EndTime = time.Now()
+	r.Duration = r.EndTime.Sub(r.StartTime).Seconds()
+	r.Outcome = OutcomeSuccess
+	for _, step := range r.Steps {
+		if o := step.outcome(); o == OutcomeFailure || o == OutcomeRunning {
+			r.Outcome = OutcomeFailure
+		}
+	}
+}
+
+// Failed returns true if the Report (or any of its steps) failed.
+func (r *Report) Failed() bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.Outcome == OutcomeFailure {
+		return true
+	}
+	for _, step := range r.Steps {
+		if step.outcome() == OutcomeFailure {
+			return true
+		}
+	}
+	return false
+}
+
+// Write serializes the Report to path in the given format.
+func (r *Report) Write(path string, format string) error {
+	var content []byte
+	var err error
+
+	snapshot := r.Snapshot()
+	switch strings.ToLower(format) {
+	case FormatJSON, "":
+		content, err = json.MarshalIndent(snapshot, "", "  ")
+	case FormatJUnit:
+		content, err = xml.MarshalIndent(snapshot.toJUnit(), "", "  ")
+		content = append([]byte(xml.Header), content...)
+	default:
+		err = fmt.Errorf("unsupported report format: %s", format)
+	}
+	if err != nil {
+		return errors.Wrap(err, "error serializing report")
+	}
+
+	if dir := filepath.Dir(path); dir != "" {
+		if err = os.MkdirAll(dir, 0o755); err != nil {
+			return errors.Wrap(err, "error creating report directory")
+		}
+	}
+	//nolint:gosec // reports are meant to be readable by CI tooling.
+	if err = os.WriteFile(path, append(content, '\n'), 0o644); err != nil {
+		return errors.Wrap(err, "error writing report")
+	}
+
+	return nil
+}
+
+// Snapshot returns a copy of the Step taken under its lock.
+func (s *Step) Snapshot() Step {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return Step{
+		Name:            s.Name,
+		StartTime:       s.StartTime,
+		EndTime:         s.EndTime,
+		Duration:        s.Duration,
+		Outcome:         s.Outcome,
+		Error:           s.Error,
+		Message:         s.Message,
+		HTTPStatusCodes: append([]int{}, s.HTTPStatusCodes...),
+		Workflows:       append([]string{}, s.Workflows...),
+		Files:           append([]string{}, s.Files...),
+	}
+}
+
+// AddHTTPStatus records an HTTP status code received during the Step.
+func (s *Step) AddHTTPStatus(code int) {
+	if s == nil {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.HTTPStatusCodes = append(s.HTTPStatusCodes, code)
+}
+
+// AddWorkflow records a workflow deployed by the Step.
+func (s *Step) AddWorkflow(name string) {
+	if s == nil {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.Workflows = append(s.Workflows, name)
+}
+
+// AddFile records a file staged by the Step.
+func (s *Step) AddFile(path string) {
+	if s == nil {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.Files = append(s.Files, path)
+}
+
+// Finish marks the end of the Step. A non-nil err fails the Step.
+func (s *Step) Finish(err error) {
+	if err != nil {
+		s.end(OutcomeFailure, err.Error())
+	} else {
+		s.end(OutcomeSuccess, "")
+	}
+}
+
+// Skip marks the Step as skipped with the given reason.
+func (s *Step) Skip(reason string) {
+	s.end(OutcomeSkipped, reason)
+}
+
+// end records the Step's outcome unless it has already finished.
+func (s *Step) end(outcome Outcome, message string) {
+	if s == nil {
+		return
+	}
+	s.mutex.Lock()
+	if s.Outcome != OutcomeRunning {
+		s.mutex.Unlock()
+		return
+	}
+	s.EndTime = time.Now()
+	s.Duration = s.EndTime.Sub(s.StartTime).Seconds()
+	s.Outcome = outcome
+	if outcome == OutcomeFailure {
+		s.Error = message
+	} else {
+		s.Message = message
+	}
+	s.mutex.Unlock()
+
+	if s.report != nil {
+		s.report.notify(s)
+	}
+}
+
+// outcome returns the Step's current Outcome.
+func (s *Step) outcome() Outcome {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.Outcome
+}
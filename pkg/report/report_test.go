@@ -0,0 +1,164 @@
+//
+// Copyright (c) 2024 Quadient Group AG
+//
+// This file is subject to the terms and conditions defined in the
+// 'LICENSE' file found in the root of this source code package.
+//
+
+package report_test
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/robertwtucker/spt-util/pkg/report"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReport_Finish(t *testing.T) {
+	rpt := report.New("foo")
+	rpt.StartStep("bar").Finish(nil)
+	rpt.Finish()
+
+	assert.Equal(t, report.OutcomeSuccess, rpt.Outcome)
+	assert.False(t, rpt.Failed())
+}
+
+func TestReport_FinishWithFailedStep(t *testing.T) {
+	rpt := report.New("foo")
+	rpt.StartStep("bar").Finish(nil)
+	rpt.StartStep("baz").Finish(errors.New("boom"))
+	rpt.Finish()
+
+	assert.Equal(t, report.OutcomeFailure, rpt.Outcome)
+	assert.True(t, rpt.Failed())
+	assert.Equal(t, "boom", rpt.Steps[1].Error)
+}
+
+func TestReport_FinishWithRunningStep(t *testing.T) {
+	rpt := report.New("foo")
+	_ = rpt.StartStep("bar")
+	rpt.Finish()
+
+	assert.Equal(t, report.OutcomeFailure, rpt.Outcome)
+}
+
+func TestStep_FinishOnlyOnce(t *testing.T) {
+	rpt := report.New("foo")
+	step := rpt.StartStep("bar")
+	step.Skip("unchanged")
+	step.Finish(errors.New("boom"))
+
+	assert.Equal(t, report.OutcomeSkipped, step.Outcome)
+	assert.Equal(t, "unchanged", step.Message)
+	assert.Empty(t, step.Error)
+}
+
+func TestReport_Snapshot(t *testing.T) {
+	rpt := report.New("foo")
+	step := rpt.StartStep("bar")
+	step.AddFile("/a")
+
+	snapshot := rpt.Snapshot()
+	step.AddFile("/b")
+	step.Finish(nil)
+
+	assert.Equal(t, []string{"/a"}, snapshot.Steps[0].Files)
+	assert.Equal(t, report.OutcomeRunning, snapshot.Steps[0].Outcome)
+}
+
+func TestReport_WriteWhileRecording(t *testing.T) {
+	rpt := report.New("foo")
+	step := rpt.StartStep("bar")
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			step.AddFile("/a")
+		}
+		step.Finish(nil)
+	}()
+
+	path := filepath.Join(t.TempDir(), "report.json")
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, rpt.Write(path, report.FormatJSON))
+	}
+	<-done
+}
+
+func TestReport_Observe(t *testing.T) {
+	rpt := report.New("foo")
+	var observed []report.Outcome
+	rpt.Observe(func(step *report.Step) {
+		assert.Equal(t, "bar", step.Name)
+		observed = append(observed, step.Outcome)
+	})
+	step := rpt.StartStep("bar")
+	step.Finish(errors.New("boom"))
+	step.Finish(nil)
+
+	assert.Equal(t, []report.Outcome{report.OutcomeRunning, report.OutcomeFailure}, observed)
+}
+
+func TestReport_WriteJSON(t *testing.T) {
+	rpt := report.New("foo")
+	step := rpt.StartStep("bar")
+	step.AddHTTPStatus(200)
+	step.AddWorkflow("baz")
+	step.Finish(nil)
+	rpt.Finish()
+
+	path := filepath.Join(t.TempDir(), "report.json")
+	assert.NoError(t, rpt.Write(path, report.FormatJSON))
+
+	content, err := os.ReadFile(path)
+	assert.NoError(t, err)
+
+	decoded := map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal(content, &decoded))
+	assert.Equal(t, "foo", decoded["command"])
+	assert.Equal(t, "success", decoded["outcome"])
+	steps, ok := decoded["steps"].([]interface{})
+	assert.True(t, ok)
+	assert.Len(t, steps, 1)
+}
+
+func TestReport_WriteJUnit(t *testing.T) {
+	rpt := report.New("foo")
+	rpt.StartStep("bar").Finish(nil)
+	rpt.StartStep("baz").Finish(errors.New("boom"))
+	rpt.StartStep("qux").Skip("unchanged")
+	rpt.Finish()
+
+	path := filepath.Join(t.TempDir(), "report.xml")
+	assert.NoError(t, rpt.Write(path, report.FormatJUnit))
+
+	content, err := os.ReadFile(path)
+	assert.NoError(t, err)
+
+	var decoded struct {
+		Suites []struct {
+			Name     string `xml:"name,attr"`
+			Tests    int    `xml:"tests,attr"`
+			Failures int    `xml:"failures,attr"`
+			Skipped  int    `xml:"skipped,attr"`
+		} `xml:"testsuite"`
+	}
+	assert.NoError(t, xml.Unmarshal(content, &decoded))
+	assert.Len(t, decoded.Suites, 1)
+	assert.Equal(t, "foo", decoded.Suites[0].Name)
+	assert.Equal(t, 3, decoded.Suites[0].Tests)
+	assert.Equal(t, 1, decoded.Suites[0].Failures)
+	assert.Equal(t, 1, decoded.Suites[0].Skipped)
+}
+
+func TestReport_WriteUnsupportedFormat(t *testing.T) {
+	rpt := report.New("foo")
+	rpt.Finish()
+
+	assert.Error(t, rpt.Write(filepath.Join(t.TempDir(), "report"), "yaml"))
+}
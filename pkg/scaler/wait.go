@@ -0,0 +1,159 @@
+//
+// Copyright (c) 2024 Quadient Group AG
+//
+// This file is subject to the terms and conditions defined in the
+// 'LICENSE' file found in the root of this source code package.
+//
+
+// Package scaler checks the availability of an Inspire Scaler instance.
+package scaler
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-http-utils/headers"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// maxErrorBody limits the response body included in probe errors.
+const maxErrorBody = 256
+
+// Probe is a GET request that must succeed for Scaler to be ready. An
+// Optional probe whose endpoint is not found (404 or 405) is skipped,
+// as not every Scaler version provides it.
+type Probe struct {
+	Name          string
+	Path          string
+	Authenticated bool
+	Optional      bool
+}
+
+// DefaultProbes check the Scaler health and version endpoints, list the
+// workflows as a lightweight authenticated call and read the ICM
+// environments through the content API that demo init imports into, so
+// that ICM is reachable from Scaler too.
+var DefaultProbes = []Probe{
+	{Name: "health", Path: "actuator/health", Optional: true},
+	{Name: "version", Path: "api/version", Optional: true},
+	{Name: "workflows", Path: "api/integration/v2/workflows", Authenticated: true},
+	{Name: "icm", Path: "api/content/v1/inspireEnvironments", Authenticated: true, Optional: true},
+}
+
+// errUnavailable is returned by an optional probe whose endpoint is not
+// found.
+var errUnavailable = errors.New("endpoint not available")
+
+// Waiter polls the probes of a Scaler instance until they all succeed.
+type Waiter struct {
+	Host       string        // Scaler base URL
+	AuthHeader string        // Authorization header of authenticated probes
+	Probes     []Probe       // probes run in order on every attempt
+	Interval   time.Duration // pause between attempts
+	Client     *http.Client  // defaults to a client with a 5s timeout
+}
+
+// Wait runs the probes until they all succeed, the timeout expires or
+// the context is done. It returns the number of attempts made and, on
+// failure, an error describing the last probe failure.
+func (w *Waiter) Wait(ctx context.Context, timeout time.Duration) (int, error) {
+	if w.Host == "" {
+		return 0, errors.New("no Scaler URL configured")
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	logger := log.WithContext(ctx)
+	attempts := 0
+	var lastErr error
+	for {
+		attempts++
+		err := w.Check(ctx)
+		if err == nil {
+			logger.WithField("attempts", attempts).Info("Scaler is ready")
+			return attempts, nil
+		}
+		if ctx.Err() != nil {
+			// Report the failure of the last complete attempt rather
+			// than the interrupted request, if there was one.
+			if lastErr == nil {
+				lastErr = err
+			}
+			return attempts, errors.Wrapf(lastErr, "Scaler not ready after %d attempt(s)", attempts)
+		}
+		lastErr = err
+		logger.WithField("attempt", attempts).Info("Scaler not ready: ", err)
+
+		timer := time.NewTimer(w.Interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return attempts, errors.Wrapf(lastErr, "Scaler not ready after %d attempt(s)", attempts)
+		case <-timer.C:
+		}
+	}
+}
+
+// Check runs the probes once and returns the first failure.
+func (w *Waiter) Check(ctx context.Context) error {
+	for _, probe := range w.Probes {
+		err := w.probe(ctx, probe)
+		if errors.Is(err, errUnavailable) {
+			log.WithFields(log.Fields{
+				"probe": probe.Name,
+				"path":  probe.Path,
+			}).Warn("skipping optional probe: ", err)
+			continue
+		}
+		if err != nil {
+			return errors.Wrap(err, probe.Name)
+		}
+		log.WithField("probe", probe.Name).Debug("probe succeeded")
+	}
+	return nil
+}
+
+// probe sends the request of a probe and checks its status.
+func (w *Waiter) probe(ctx context.Context, probe Probe) error {
+	url := strings.TrimSuffix(w.Host, "/") + "/" + strings.TrimPrefix(probe.Path, "/")
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return errors.Wrap(err, "error creating request")
+	}
+	request.Header.Set(headers.Accept, "application/json")
+	if probe.Authenticated {
+		request.Header.Set(headers.Authorization, w.AuthHeader)
+	}
+
+	client := w.Client
+	if client == nil {
+		//nolint:gomnd // probes are expected to respond quickly.
+		client = &http.Client{Timeout: 5 * time.Second}
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = response.Body.Close() }()
+
+	if probe.Optional &&
+		(response.StatusCode == http.StatusNotFound || response.StatusCode == http.StatusMethodNotAllowed) {
+		return errors.Wrapf(errUnavailable, "GET %s: %s", request.URL.Path, response.Status)
+	}
+	if response.StatusCode >= http.StatusBadRequest {
+		body, _ := io.ReadAll(io.LimitReader(response.Body, maxErrorBody))
+		message := strings.TrimSpace(string(body))
+		if message == "" {
+			return errors.Errorf("GET %s: %s", request.URL.Path, response.Status)
+		}
+		return errors.Errorf("GET %s: %s: %s", request.URL.Path, response.Status, message)
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+//
+// Copyright (c) 2024 Quadient Group AG
+//
+// This file is subject to the terms and conditions defined in the
+// 'LICENSE' file found in the root of this source code package.
+//
+
+package scaler_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/robertwtucker/spt-util/pkg/scaler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const authHeader = "Basic dXNlcjpwYXNz"
+
+// newServer returns a Scaler stub that becomes healthy after the given
+// number of health requests and requires authentication for the
+// workflows.
+func newServer(t *testing.T, unhealthy int32) *httptest.Server {
+	t.Helper()
+	var healthRequests int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/actuator/health", func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&healthRequests, 1) <= unhealthy {
+			http.Error(w, "starting", http.StatusServiceUnavailable)
+		}
+	})
+	mux.HandleFunc("/api/version", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/api/integration/v2/workflows", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != authHeader {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	})
+	mux.HandleFunc("/api/content/v1/inspireEnvironments", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != authHeader {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestWaiter_Wait(t *testing.T) {
+	server := newServer(t, 2)
+	waiter := &scaler.Waiter{
+		Host:       server.URL + "/",
+		AuthHeader: authHeader,
+		Probes:     scaler.DefaultProbes,
+		Interval:   time.Millisecond,
+	}
+
+	attempts, err := waiter.Wait(context.Background(), time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, 3, attempts)
+}
+
+func TestWaiter_WaitTimeout(t *testing.T) {
+	server := newServer(t, 0)
+	waiter := &scaler.Waiter{
+		Host:       server.URL,
+		AuthHeader: "Basic invalid",
+		Probes:     scaler.DefaultProbes,
+		Interval:   10 * time.Millisecond,
+	}
+
+	attempts, err := waiter.Wait(context.Background(), 50*time.Millisecond)
+	require.Error(t, err)
+	assert.Greater(t, attempts, 1)
+	assert.Contains(t, err.Error(), "workflows: GET /api/integration/v2/workflows: 401 Unauthorized")
+}
+
+func TestWaiter_WaitWithoutHost(t *testing.T) {
+	_, err := (&scaler.Waiter{Probes: scaler.DefaultProbes}).Wait(context.Background(), time.Second)
+	assert.Error(t, err)
+}
+
+func TestWaiter_Check(t *testing.T) {
+	server := newServer(t, 1)
+	waiter := &scaler.Waiter{Host: server.URL, Probes: scaler.DefaultProbes[:1]}
+
+	err := waiter.Check(context.Background())
+	require.Error(t, err)
+	assert.Equal(t, "health: GET /actuator/health: 503 Service Unavailable: starting", err.Error())
+	assert.NoError(t, waiter.Check(context.Background()))
+}
+
+func TestWaiter_CheckICM(t *testing.T) {
+	var icmReady int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/content/v1/inspireEnvironments", func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&icmReady) == 0 {
+			http.Error(w, "ICM not reachable", http.StatusBadGateway)
+		}
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	waiter := &scaler.Waiter{Host: server.URL, AuthHeader: authHeader, Probes: scaler.DefaultProbes[3:]}
+
+	err := waiter.Check(context.Background())
+	require.Error(t, err)
+	assert.Equal(t, "icm: GET /api/content/v1/inspireEnvironments: 502 Bad Gateway: ICM not reachable", err.Error())
+	atomic.StoreInt32(&icmReady, 1)
+	assert.NoError(t, waiter.Check(context.Background()))
+}
+
+func TestWaiter_CheckEndpointNotFound(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		probe  scaler.Probe
+		err    bool
+	}{
+		{name: "optional not found", status: http.StatusNotFound, probe: scaler.DefaultProbes[0]},
+		{name: "optional method not allowed", status: http.StatusMethodNotAllowed, probe: scaler.DefaultProbes[3]},
+		{name: "optional failing", status: http.StatusInternalServerError, probe: scaler.DefaultProbes[1], err: true},
+		{name: "required not found", status: http.StatusNotFound, probe: scaler.DefaultProbes[2], err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+			waiter := &scaler.Waiter{Host: server.URL, AuthHeader: authHeader, Probes: []scaler.Probe{tt.probe}}
+
+			err := waiter.Check(context.Background())
+			if tt.err {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
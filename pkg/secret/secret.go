@@ -0,0 +1,69 @@
+//
+// Copyright (c) 2024 Quadient Group AG
+//
+// This file is subject to the terms and conditions defined in the
+// 'LICENSE' file found in the root of this source code package.
+//
+
+// Package secret resolves references to secrets kept outside of the
+// configuration file.
+package secret
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Reference prefixes.
+const (
+	FilePrefix = "file://"
+	EnvPrefix  = "env:"
+)
+
+// IsReference reports whether value refers to a secret instead of
+// holding it.
+func IsReference(value string) bool {
+	return strings.HasPrefix(value, FilePrefix) || strings.HasPrefix(value, EnvPrefix)
+}
+
+// Resolve returns the secret a reference refers to: the content of a
+// file (file:///path/to/secret) or the value of an environment variable
+// (env:NAME). Values that are not references are returned unchanged.
+func Resolve(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, FilePrefix):
+		return readFile(strings.TrimPrefix(value, FilePrefix))
+	case strings.HasPrefix(value, EnvPrefix):
+		name := strings.TrimPrefix(value, EnvPrefix)
+		resolved, ok := os.LookupEnv(name)
+		if !ok {
+			return "", errors.Errorf("secret environment variable not set: %s", name)
+		}
+		return resolved, nil
+	default:
+		return value, nil
+	}
+}
+
+// FromDir returns the value of a key in a mounted Kubernetes secret,
+// i.e. the content of the file named key in dir. A missing key yields
+// an empty string.
+func FromDir(dir string, key string) (string, error) {
+	path := filepath.Join(dir, key)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return "", nil
+	}
+	return readFile(path)
+}
+
+// readFile reads a secret file, dropping a trailing line break.
+func readFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", errors.Wrap(err, "error reading secret file")
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
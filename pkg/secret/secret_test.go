@@ -0,0 +1,56 @@
+//
+// Copyright (c) 2024 Quadient Group AG
+//
+// This file is subject to the terms and conditions defined in the
+// 'LICENSE' file found in the root of this source code package.
+//
+
+package secret_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/robertwtucker/spt-util/pkg/secret"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolve(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "password")
+	require.NoError(t, os.WriteFile(path, []byte("s3cret\n"), 0o600))
+	t.Setenv("SPT_TEST_SECRET", "from-env")
+
+	value, err := secret.Resolve("file://" + path)
+	require.NoError(t, err)
+	assert.Equal(t, "s3cret", value)
+
+	value, err = secret.Resolve("env:SPT_TEST_SECRET")
+	require.NoError(t, err)
+	assert.Equal(t, "from-env", value)
+
+	value, err = secret.Resolve("plain")
+	require.NoError(t, err)
+	assert.Equal(t, "plain", value)
+	assert.False(t, secret.IsReference("plain"))
+
+	_, err = secret.Resolve("env:SPT_TEST_UNSET")
+	assert.Error(t, err)
+	_, err = secret.Resolve("file://" + filepath.Join(dir, "missing"))
+	assert.Error(t, err)
+}
+
+func TestFromDir(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "username"), []byte("admin"), 0o600))
+
+	value, err := secret.FromDir(dir, "username")
+	require.NoError(t, err)
+	assert.Equal(t, "admin", value)
+
+	value, err = secret.FromDir(dir, "password")
+	require.NoError(t, err)
+	assert.Empty(t, value)
+}
@@ -0,0 +1,160 @@
+//
+// Copyright (c) 2024 Quadient Group AG
+//
+// This file is subject to the terms and conditions defined in the
+// 'LICENSE' file found in the root of this source code package.
+//
+
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/robertwtucker/spt-util/pkg/report"
+)
+
+// Job statuses.
+const (
+	StatusQueued    = "queued"
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+// Types of job events.
+const (
+	EventLog    = "log"
+	EventStep   = "step"
+	EventStatus = "status"
+)
+
+// Operation runs a demo operation, recording its steps in the report.
+// The context carries the span tracing the job and the job's ID; log
+// entries written with it, e.g. log.WithContext(ctx), are published as
+// events of the job.
+type Operation func(ctx context.Context, rpt *report.Report) error
+
+// jobKey is the context key of the job ID.
+type jobKey struct{}
+
+// withJob returns a copy of ctx carrying the job ID.
+func withJob(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, jobKey{}, id)
+}
+
+// JobID returns the ID of the job whose operation runs with ctx.
+func JobID(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(jobKey{}).(string)
+	return id, ok
+}
+
+// Event is a log entry, step update or status change of a job. Events
+// are numbered per job, starting at 1.
+type Event struct {
+	ID    int         `json:"id"`
+	JobID string      `json:"jobId"`
+	Type  string      `json:"type"`
+	Time  time.Time   `json:"time"`
+	Data  interface{} `json:"data"`
+}
+
+// LogEntry is the data of a log event.
+type LogEntry struct {
+	Level   string                 `json:"level"`
+	Message string                 `json:"message"`
+	Fields  map[string]interface{} `json:"fields,omitempty"`
+}
+
+// StatusChange is the data of a status event.
+type StatusChange struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Job is a run of an operation. Its steps are the latest state of the
+// steps recorded by the operation.
+type Job struct {
+	ID        string         `json:"id"`
+	Operation string         `json:"operation"`
+	Status    string         `json:"status"`
+	Error     string         `json:"error,omitempty"`
+	Created   time.Time      `json:"created"`
+	Started   *time.Time     `json:"started,omitempty"`
+	Finished  *time.Time     `json:"finished,omitempty"`
+	Steps     []*report.Step `json:"steps"`
+	events    []Event
+	changed   chan struct{} // closed when events are added
+}
+
+// newJob creates a queued job for the named operation.
+func newJob(operation string) (*Job, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	return &Job{
+		ID:        hex.EncodeToString(id),
+		Operation: operation,
+		Status:    StatusQueued,
+		Created:   time.Now().UTC(),
+		Steps:     []*report.Step{},
+		changed:   make(chan struct{}),
+	}, nil
+}
+
+// done reports whether the job has finished.
+func (j *Job) done() bool {
+	return j.Status == StatusSucceeded || j.Status == StatusFailed
+}
+
+// add appends an event, applies it to the job and wakes up the clients
+// waiting for events.
+func (j *Job) add(event Event) {
+	event.ID = len(j.events) + 1
+	j.events = append(j.events, event)
+
+	switch data := event.Data.(type) {
+	case report.Step:
+		j.putStep(&data)
+	case StatusChange:
+		j.Status = data.Status
+		j.Error = data.Error
+		now := event.Time
+		if data.Status == StatusRunning {
+			j.Started = &now
+		} else if j.done() {
+			j.Finished = &now
+		}
+	}
+
+	close(j.changed)
+	j.changed = make(chan struct{})
+}
+
+// putStep adds a step or replaces the running step of the same name.
+func (j *Job) putStep(step *report.Step) {
+	for i := len(j.Steps) - 1; i >= 0; i-- {
+		if j.Steps[i].Name == step.Name && j.Steps[i].Outcome == report.OutcomeRunning {
+			j.Steps[i] = step
+			return
+		}
+	}
+	j.Steps = append(j.Steps, step)
+}
+
+// copy returns a copy of the job without its events, for encoding.
+func (j *Job) copy() *Job {
+	return &Job{
+		ID:        j.ID,
+		Operation: j.Operation,
+		Status:    j.Status,
+		Error:     j.Error,
+		Created:   j.Created,
+		Started:   j.Started,
+		Finished:  j.Finished,
+		Steps:     append([]*report.Step{}, j.Steps...),
+	}
+}
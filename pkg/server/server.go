@@ -0,0 +1,405 @@
+//
+// Copyright (c) 2024 Quadient Group AG
+//
+// This file is subject to the terms and conditions defined in the
+// 'LICENSE' file found in the root of this source code package.
+//
+
+// Package server exposes demo operations as a REST API. Operations run
+// as asynchronous jobs, one at a time; their log entries, step progress
+// and status changes are published on an event bus, recorded per job
+// and streamed to clients as server-sent events.
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-http-utils/headers"
+	"github.com/pkg/errors"
+	"github.com/robertwtucker/spt-util/pkg/eventbus"
+	"github.com/robertwtucker/spt-util/pkg/metrics"
+	"github.com/robertwtucker/spt-util/pkg/report"
+	"github.com/robertwtucker/spt-util/pkg/tracing"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Limits of the job store.
+const (
+	maxQueued = 16  // jobs waiting to run
+	maxJobs   = 100 // jobs kept, including finished ones
+)
+
+// apiPrefix is the path prefix of the API endpoints.
+const apiPrefix = "api/v1"
+
+// Server runs demo operations as jobs and serves the API.
+type Server struct {
+	operations map[string]Operation
+	token      string
+	bus        *eventbus.EventBus
+	queue      chan *Job
+	mutex      sync.Mutex
+	jobs       map[string]*Job
+	order      []*Job // oldest first
+}
+
+// New creates a Server for the named operations and starts running the
+// jobs submitted. If token is not empty, API requests must present it
+// as a bearer token.
+func New(operations map[string]Operation, token string) *Server {
+	s := &Server{
+		operations: operations,
+		token:      token,
+		bus:        eventbus.NewEventBus(),
+		queue:      make(chan *Job, maxQueued),
+		jobs:       map[string]*Job{},
+	}
+
+	events := eventbus.NewEventChannel()
+	s.bus.SubscribeEventChannel(events, eventbus.JobEvents)
+	go s.record(events)
+	go s.work()
+	return s
+}
+
+// Submit queues a job running the named operation.
+func (s *Server) Submit(operation string) (*Job, error) {
+	if _, ok := s.operations[operation]; !ok {
+		return nil, errors.Errorf("unknown operation %q", operation)
+	}
+	job, err := newJob(operation)
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating job")
+	}
+
+	s.mutex.Lock()
+	select {
+	case s.queue <- job:
+	default:
+		s.mutex.Unlock()
+		return nil, errors.New("too many jobs queued")
+	}
+	s.jobs[job.ID] = job
+	s.order = append(s.order, job)
+	s.prune()
+	result := job.copy()
+	s.mutex.Unlock()
+
+	log.WithFields(log.Fields{"job": job.ID, "operation": operation}).Debug("queued job")
+	return result, nil
+}
+
+// Job returns a copy of the job with the given ID, if it exists.
+func (s *Server) Job(id string) (*Job, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil, false
+	}
+	return job.copy(), true
+}
+
+// Jobs returns copies of the jobs, most recent first.
+func (s *Server) Jobs() []*Job {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	jobs := make([]*Job, 0, len(s.order))
+	for i := len(s.order) - 1; i >= 0; i-- {
+		jobs = append(jobs, s.order[i].copy())
+	}
+	return jobs
+}
+
+// prune drops the oldest finished jobs beyond the store limit.
+func (s *Server) prune() {
+	for i := 0; len(s.order) > maxJobs && i < len(s.order); {
+		if job := s.order[i]; job.done() {
+			delete(s.jobs, job.ID)
+			s.order = append(s.order[:i], s.order[i+1:]...)
+			continue
+		}
+		i++
+	}
+}
+
+// work runs the queued jobs one at a time.
+func (s *Server) work() {
+	for job := range s.queue {
+		s.run(job)
+	}
+}
+
+// run runs a job's operation, publishing its progress. Each job is
+// traced as a trace of its own.
+func (s *Server) run(job *Job) {
+	s.publish(eventbus.JobStatus, job.ID, EventStatus, StatusChange{Status: StatusRunning})
+	log.WithFields(log.Fields{"job": job.ID, "operation": job.Operation}).Info("started job")
+
+	rpt := report.New(job.Operation)
+	rpt.Observe(func(step *report.Step) {
+		s.publish(eventbus.JobStep, job.ID, EventStep, step.Snapshot())
+	})
+	ctx, span := tracing.Tracer().Start(withJob(context.Background(), job.ID), "job "+job.Operation,
+		trace.WithNewRoot(),
+		trace.WithAttributes(attribute.String("job.id", job.ID)),
+	)
+	err := s.operations[job.Operation](ctx, rpt)
+	rpt.Finish()
+	metrics.ObserveReport(rpt)
+	if err == nil && rpt.Failed() {
+		err = errors.New("one or more steps failed")
+	}
+
+	change := StatusChange{Status: StatusSucceeded}
+	if err != nil {
+		log.WithField("job", job.ID).Error("job failed: ", err)
+		change = StatusChange{Status: StatusFailed, Error: err.Error()}
+		tracing.Fail(span, err)
+	} else {
+		log.WithField("job", job.ID).Info("job succeeded")
+	}
+	span.End()
+	s.publish(eventbus.JobStatus, job.ID, EventStatus, change)
+}
+
+// publish publishes a job event on the event bus.
+func (s *Server) publish(name string, jobID string, eventType string, data interface{}) {
+	s.bus.PublishEvent(name, Event{JobID: jobID, Type: eventType, Time: time.Now().UTC(), Data: data})
+}
+
+// record adds the events published on the bus to their jobs.
+func (s *Server) record(events eventbus.EventChannel) {
+	for e := range events {
+		if event, ok := e.Data.(Event); ok {
+			s.mutex.Lock()
+			if job, found := s.jobs[event.JobID]; found {
+				job.add(event)
+			}
+			s.mutex.Unlock()
+		}
+		e.Done()
+	}
+}
+
+// LogHook returns a logrus hook publishing the log entries of a job as
+// its events. Entries belong to a job if they carry its context (see
+// Operation) or its ID in a job field; other entries are not published.
+func (s *Server) LogHook() log.Hook {
+	return &logHook{server: s}
+}
+
+// logHook publishes log entries as job events.
+type logHook struct {
+	server *Server
+}
+
+// Levels implements logrus.Hook. Debug entries, which include those of
+// the event bus itself, are not published.
+func (h *logHook) Levels() []log.Level {
+	return []log.Level{log.PanicLevel, log.FatalLevel, log.ErrorLevel, log.WarnLevel, log.InfoLevel}
+}
+
+// Fire implements logrus.Hook.
+func (h *logHook) Fire(entry *log.Entry) error {
+	id, ok := entry.Data["job"].(string)
+	if !ok && entry.Context != nil {
+		id, ok = JobID(entry.Context)
+	}
+	if !ok {
+		return nil
+	}
+
+	fields := make(map[string]interface{}, len(entry.Data))
+	for key, value := range entry.Data {
+		if err, ok := value.(error); ok {
+			value = err.Error()
+		}
+		fields[key] = value
+	}
+	h.server.publish(eventbus.JobLog, id, EventLog, LogEntry{
+		Level:   entry.Level.String(),
+		Message: entry.Message,
+		Fields:  fields,
+	})
+	return nil
+}
+
+// ServeHTTP implements http.Handler.
+//
+//	GET  /healthz                         liveness
+//	GET  /metrics                         Prometheus metrics
+//	GET  /api/v1/operations               operation names
+//	POST /api/v1/operations/{name}        submit a job (202, Location)
+//	GET  /api/v1/jobs                     jobs, most recent first
+//	GET  /api/v1/jobs/{id}                job status and steps
+//	GET  /api/v1/jobs/{id}/events         job events (text/event-stream)
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(r.URL.Path, "/")
+	switch path {
+	case "healthz":
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+		return
+	case "metrics":
+		metrics.Handler().ServeHTTP(w, r)
+		return
+	}
+	if !strings.HasPrefix(path+"/", apiPrefix+"/") {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	if !s.authorized(r) {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	parts := strings.Split(strings.TrimPrefix(strings.TrimPrefix(path, apiPrefix), "/"), "/")
+	switch {
+	case len(parts) == 1 && parts[0] == "operations":
+		s.handle(w, r, http.MethodGet, s.listOperations)
+	case len(parts) == 2 && parts[0] == "operations":
+		s.handle(w, r, http.MethodPost, func(w http.ResponseWriter, r *http.Request) { s.submit(w, parts[1]) })
+	case len(parts) == 1 && parts[0] == "jobs":
+		s.handle(w, r, http.MethodGet, func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, http.StatusOK, s.Jobs())
+		})
+	case len(parts) == 2 && parts[0] == "jobs":
+		s.handle(w, r, http.MethodGet, func(w http.ResponseWriter, r *http.Request) { s.getJob(w, parts[1]) })
+	case len(parts) == 3 && parts[0] == "jobs" && parts[2] == "events":
+		s.handle(w, r, http.MethodGet, func(w http.ResponseWriter, r *http.Request) { s.streamEvents(w, r, parts[1]) })
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+// handle calls the handler if the request uses the given method.
+func (s *Server) handle(w http.ResponseWriter, r *http.Request, method string, handler http.HandlerFunc) {
+	if r.Method != method {
+		w.Header().Set("Allow", method)
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	handler(w, r)
+}
+
+// authorized checks the bearer token of a request, if one is required.
+func (s *Server) authorized(r *http.Request) bool {
+	if s.token == "" {
+		return true
+	}
+	token := strings.TrimPrefix(r.Header.Get(headers.Authorization), "Bearer ")
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) == 1
+}
+
+// listOperations writes the names of the operations.
+func (s *Server) listOperations(w http.ResponseWriter, _ *http.Request) {
+	names := make([]string, 0, len(s.operations))
+	for name := range s.operations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	writeJSON(w, http.StatusOK, names)
+}
+
+// submit queues a job and writes it.
+func (s *Server) submit(w http.ResponseWriter, operation string) {
+	if _, ok := s.operations[operation]; !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("unknown operation %q", operation))
+		return
+	}
+	job, err := s.Submit(operation)
+	if err != nil {
+		writeError(w, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+	w.Header().Set(headers.Location, "/"+apiPrefix+"/jobs/"+job.ID)
+	writeJSON(w, http.StatusAccepted, job)
+}
+
+// getJob writes a job.
+func (s *Server) getJob(w http.ResponseWriter, id string) {
+	job, ok := s.Job(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "unknown job")
+		return
+	}
+	writeJSON(w, http.StatusOK, job)
+}
+
+// streamEvents writes the events of a job as server-sent events until
+// the job has finished or the client disconnects. Clients reconnecting
+// with a Last-Event-ID header receive the events after that one.
+func (s *Server) streamEvents(w http.ResponseWriter, r *http.Request, id string) {
+	s.mutex.Lock()
+	job, ok := s.jobs[id]
+	s.mutex.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, "unknown job")
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming not supported")
+		return
+	}
+
+	next, _ := strconv.Atoi(r.Header.Get("Last-Event-ID"))
+	w.Header().Set(headers.ContentType, "text/event-stream")
+	w.Header().Set(headers.CacheControl, "no-cache")
+	w.WriteHeader(http.StatusOK)
+	for {
+		s.mutex.Lock()
+		var events []Event
+		if next < len(job.events) {
+			events = append(events, job.events[next:]...)
+		}
+		next = len(job.events)
+		done, changed := job.done(), job.changed
+		s.mutex.Unlock()
+
+		for _, event := range events {
+			data, err := json.Marshal(event)
+			if err != nil {
+				log.Debug("unable to encode job event: ", err)
+				continue
+			}
+			_, _ = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+		}
+		flusher.Flush()
+		if done {
+			return
+		}
+
+		select {
+		case <-changed:
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// writeJSON writes a JSON response.
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set(headers.ContentType, "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Debug("unable to write response: ", err)
+	}
+}
+
+// writeError writes a JSON error response.
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
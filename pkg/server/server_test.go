@@ -0,0 +1,173 @@
+//
+// Copyright (c) 2024 Quadient Group AG
+//
+// This file is subject to the terms and conditions defined in the
+// 'LICENSE' file found in the root of this source code package.
+//
+
+package server_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/robertwtucker/spt-util/pkg/report"
+	"github.com/robertwtucker/spt-util/pkg/server"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newServer starts a test server with an operation that succeeds and
+// one that fails.
+func newServer(t *testing.T, token string) (*server.Server, *httptest.Server) {
+	t.Helper()
+	srv := server.New(map[string]server.Operation{
+		"stage": func(ctx context.Context, rpt *report.Report) error {
+			log.WithContext(ctx).WithField("files", 1).Info("staging")
+			// Entries without the job's context, e.g. of other requests.
+			log.Info("unrelated")
+			rpt.StartStep("copy").Finish(nil)
+			return nil
+		},
+		"init": func(_ context.Context, rpt *report.Report) error {
+			rpt.StartStep("import").Finish(errors.New("boom"))
+			return nil
+		},
+	}, token)
+	log.AddHook(srv.LogHook())
+	t.Cleanup(func() { log.StandardLogger().ReplaceHooks(log.LevelHooks{}) })
+
+	httpServer := httptest.NewServer(srv)
+	t.Cleanup(httpServer.Close)
+	return srv, httpServer
+}
+
+// request sends a request to the test server and decodes the response.
+func request(t *testing.T, method string, url string, token string, body interface{}) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(method, url, nil)
+	require.NoError(t, err)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	response, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer func() { _ = response.Body.Close() }()
+	if body != nil {
+		require.NoError(t, json.NewDecoder(response.Body).Decode(body))
+	}
+	return response
+}
+
+// waitForJob waits for a job to finish.
+func waitForJob(t *testing.T, srv *server.Server, id string) *server.Job {
+	t.Helper()
+	var job *server.Job
+	require.Eventually(t, func() bool {
+		var ok bool
+		job, ok = srv.Job(id)
+		return ok && (job.Status == server.StatusSucceeded || job.Status == server.StatusFailed)
+	}, 5*time.Second, 10*time.Millisecond)
+	return job
+}
+
+func TestServer_SubmitJob(t *testing.T) {
+	srv, httpServer := newServer(t, "")
+
+	job := &server.Job{}
+	response := request(t, http.MethodPost, httpServer.URL+"/api/v1/operations/stage", "", job)
+	assert.Equal(t, http.StatusAccepted, response.StatusCode)
+	assert.Equal(t, "/api/v1/jobs/"+job.ID, response.Header.Get("Location"))
+
+	finished := waitForJob(t, srv, job.ID)
+	assert.Equal(t, server.StatusSucceeded, finished.Status)
+	require.Len(t, finished.Steps, 1)
+	assert.Equal(t, report.OutcomeSuccess, finished.Steps[0].Outcome)
+
+	fetched := &server.Job{}
+	request(t, http.MethodGet, httpServer.URL+"/api/v1/jobs/"+job.ID, "", fetched)
+	assert.Equal(t, server.StatusSucceeded, fetched.Status)
+	assert.NotNil(t, fetched.Finished)
+}
+
+func TestServer_FailedJob(t *testing.T) {
+	srv, _ := newServer(t, "")
+
+	job, err := srv.Submit("init")
+	require.NoError(t, err)
+	finished := waitForJob(t, srv, job.ID)
+	assert.Equal(t, server.StatusFailed, finished.Status)
+	assert.Equal(t, "one or more steps failed", finished.Error)
+
+	_, err = srv.Submit("reset")
+	assert.Error(t, err)
+}
+
+func TestServer_StreamEvents(t *testing.T) {
+	srv, httpServer := newServer(t, "")
+	job, err := srv.Submit("stage")
+	require.NoError(t, err)
+
+	response, err := http.Get(httpServer.URL + "/api/v1/jobs/" + job.ID + "/events")
+	require.NoError(t, err)
+	defer func() { _ = response.Body.Close() }()
+	assert.Equal(t, "text/event-stream", response.Header.Get("Content-Type"))
+
+	// The stream ends when the job has finished.
+	var types []string
+	var messages []string
+	scanner := bufio.NewScanner(response.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "event: ") {
+			types = append(types, strings.TrimPrefix(line, "event: "))
+		}
+		if strings.HasPrefix(line, "data: ") {
+			event := struct {
+				Data struct{ Message string }
+			}{}
+			require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event))
+			messages = append(messages, event.Data.Message)
+		}
+	}
+	require.NoError(t, scanner.Err())
+	assert.Equal(t, server.EventStatus, types[0])
+	assert.Contains(t, types, server.EventStep)
+	assert.Contains(t, messages, "staging")
+	assert.NotContains(t, messages, "unrelated")
+	assert.Equal(t, server.EventStatus, types[len(types)-1])
+}
+
+func TestServer_Unauthorized(t *testing.T) {
+	_, httpServer := newServer(t, "s3cret")
+
+	response := request(t, http.MethodGet, httpServer.URL+"/api/v1/operations", "", nil)
+	assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
+
+	var operations []string
+	response = request(t, http.MethodGet, httpServer.URL+"/api/v1/operations", "s3cret", &operations)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, []string{"init", "stage"}, operations)
+
+	response = request(t, http.MethodGet, httpServer.URL+"/healthz", "", nil)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+}
+
+func TestServer_NotFound(t *testing.T) {
+	_, httpServer := newServer(t, "")
+
+	response := request(t, http.MethodPost, httpServer.URL+"/api/v1/operations/reset", "", nil)
+	assert.Equal(t, http.StatusNotFound, response.StatusCode)
+	response = request(t, http.MethodGet, httpServer.URL+"/api/v1/jobs/unknown", "", nil)
+	assert.Equal(t, http.StatusNotFound, response.StatusCode)
+	response = request(t, http.MethodGet, httpServer.URL+"/api/v1/operations/stage", "", nil)
+	assert.Equal(t, http.StatusMethodNotAllowed, response.StatusCode)
+}
@@ -0,0 +1,96 @@
+//
+// Copyright (c) 2024 Quadient Group AG
+//
+// This file is subject to the terms and conditions defined in the
+// 'LICENSE' file found in the root of this source code package.
+//
+
+package stage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Comparison modes used to decide whether a destination is unchanged.
+const (
+	CompareSize    = "size"
+	CompareModTime = "mtime"
+	CompareHash    = "hash"
+)
+
+// FileSHA256 returns the hex-encoded SHA-256 digest of the file at path.
+func FileSHA256(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", errors.Wrap(err, "error opening file to hash")
+	}
+	defer func() { _ = file.Close() }()
+
+	hash := sha256.New()
+	if _, err = io.Copy(hash, file); err != nil {
+		return "", errors.Wrap(err, "error hashing file")
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// verifyChecksum returns an error if the file at path does not have
+// the expected SHA-256 digest.
+func verifyChecksum(path string, expected string) error {
+	actual, err := FileSHA256(path)
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(actual, expected) {
+		return errors.Errorf("checksum mismatch for %s: expected %s, got %s", path, expected, actual)
+	}
+	return nil
+}
+
+// unchanged reports whether dest already matches the source file
+// (described by srcInfo) using the given comparison mode. When an
+// expected digest is given, hash comparisons use it instead of
+// hashing the source.
+func unchanged(mode string, srcInfo os.FileInfo, src string, dest string, expected string) (bool, error) {
+	destInfo, err := os.Stat(dest)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, errors.Wrap(err, "error reading destination file info")
+	}
+	if destInfo.IsDir() || destInfo.Size() != srcInfo.Size() {
+		return false, nil
+	}
+
+	switch strings.ToLower(mode) {
+	case CompareSize:
+		return true, nil
+	case CompareHash:
+		var destHash string
+		if destHash, err = FileSHA256(dest); err != nil {
+			return false, err
+		}
+		if expected == "" {
+			if expected, err = FileSHA256(src); err != nil {
+				return false, err
+			}
+		}
+		return strings.EqualFold(destHash, expected), nil
+	case CompareModTime, "":
+		// Timestamps are only preserved to the precision supported by
+		// the destination file system, so compare whole seconds.
+		return srcInfo.ModTime().Truncate(time.Second).Equal(
+			destInfo.ModTime().Truncate(time.Second),
+		), nil
+	default:
+		return false, errors.Errorf("unsupported compare mode: %s", mode)
+	}
+}
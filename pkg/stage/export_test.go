@@ -0,0 +1,19 @@
+//
+// Copyright (c) 2024 Quadient Group AG
+//
+// This file is subject to the terms and conditions defined in the
+// 'LICENSE' file found in the root of this source code package.
+//
+
+package stage
+
+import (
+	"net/http"
+	"time"
+)
+
+// SignS3Request exposes the S3 request signer to tests.
+func SignS3Request(request *http.Request, accessKey, secretKey, region string, now time.Time) {
+	creds := s3Credentials{AccessKey: accessKey, SecretKey: secretKey, Region: region}
+	creds.sign(request, now)
+}
@@ -0,0 +1,350 @@
+//
+// Copyright (c) 2024 Quadient Group AG
+//
+// This file is subject to the terms and conditions defined in the
+// 'LICENSE' file found in the root of this source code package.
+//
+
+package stage
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// Archive formats supported by the extract action.
+const (
+	FormatZip   = "zip"
+	FormatTarGz = "tar.gz"
+)
+
+// DefaultMaxEntrySize limits the size of an extracted archive entry
+// when no maximum is configured.
+const DefaultMaxEntrySize int64 = 4 << 30
+
+// archiveEntry describes a single regular file read from an archive.
+type archiveEntry struct {
+	name    string
+	mode    os.FileMode
+	size    int64
+	modTime time.Time
+	open    func() (io.ReadCloser, error)
+}
+
+// archiveFormat returns the archive format of the entry's source,
+// either as configured or as inferred from the file extension.
+func (f *FilesToCopy) archiveFormat() (string, error) {
+	if f.Format != "" {
+		format := strings.ToLower(f.Format)
+		if format != FormatZip && format != FormatTarGz {
+			return "", errors.Errorf("unsupported archive format: %s", f.Format)
+		}
+		return format, nil
+	}
+
+	name := strings.ToLower(f.Source)
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		return FormatZip, nil
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return FormatTarGz, nil
+	default:
+		return "", errors.Errorf("unable to determine archive format: %s", f.Source)
+	}
+}
+
+// extract unpacks the entry's source archive into its destination
+// directory.
+func (f *FilesToCopy) extract(result *Result, opts Options) error {
+	format, err := f.archiveFormat()
+	if err != nil {
+		return err
+	}
+	if f.SHA256 != "" {
+		if err = verifyChecksum(f.Source, f.SHA256); err != nil {
+			return err
+		}
+	}
+
+	dest, err := filepath.Abs(f.Destination)
+	if err != nil {
+		return errors.Wrap(err, "error resolving destination path")
+	}
+	if err = f.mkdirAll(dest, opts.Transaction); err != nil {
+		return err
+	}
+
+	walk := walkZip
+	if format == FormatTarGz {
+		walk = walkTarGz
+	}
+	targets := map[string]string{}
+	return walk(f.Source, func(entry archiveEntry) error {
+		return f.extractEntry(dest, entry, targets, result, opts)
+	})
+}
+
+// extractEntry writes a single archive entry below dest, applying the
+// strip-components setting, include/exclude filters, flatten option
+// and the skip unchanged policy.
+func (f *FilesToCopy) extractEntry(
+	dest string, entry archiveEntry, targets map[string]string, result *Result, opts Options,
+) error {
+	name, ok := stripComponents(entry.name, f.StripComponents)
+	if !ok || !f.matches(name) {
+		return nil
+	}
+
+	target, err := f.target(dest, name)
+	if err != nil {
+		return err
+	}
+	if other, found := targets[target]; found {
+		return errors.Errorf("both %s and %s would be extracted to %s", other, entry.name, target)
+	}
+	targets[target] = entry.name
+
+	var skip bool
+	if skip, err = f.overwriteSkip(entryInfo{entry}, target); err != nil {
+		return err
+	}
+	if skip {
+		result.skipped(target)
+		return nil
+	}
+
+	// Archive entries can only be hashed by reading them, which is done
+	// while writing; other comparisons use the entry's header.
+	var existingHash string
+	if f.SkipUnchanged {
+		if strings.EqualFold(f.Compare, CompareHash) {
+			if existingHash, err = existingFileHash(target, entry.size); err != nil {
+				return err
+			}
+		} else {
+			if skip, err = unchanged(f.Compare, entryInfo{entry}, "", target, ""); err != nil {
+				return err
+			}
+			if skip {
+				log.WithField("dest", target).Debug("skipping unchanged file")
+				result.skipped(target)
+				return nil
+			}
+		}
+	}
+
+	log.WithFields(log.Fields{
+		"entry": entry.name,
+		"dest":  target,
+	}).Debug("extracting archive entry")
+	if err = f.mkdirAll(filepath.Dir(target), opts.Transaction); err != nil {
+		return err
+	}
+	written, err := writeEntry(target, entry, existingHash, opts)
+	if err != nil {
+		return err
+	}
+	if written {
+		result.copied(target)
+		if err = f.applyAttributes(target, false); err != nil {
+			return err
+		}
+	} else {
+		log.WithField("dest", target).Debug("skipping unchanged file")
+		result.skipped(target)
+	}
+	return nil
+}
+
+// existingFileHash returns the SHA-256 digest of the file at path if
+// it exists with the given size, or an empty string otherwise.
+func existingFileHash(path string, size int64) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() || info.Size() != size {
+		return "", nil //nolint:nilerr // a missing file is simply not unchanged.
+	}
+	return FileSHA256(path)
+}
+
+// maxEntrySize returns the maximum size of an extracted archive entry.
+func (o *Options) maxEntrySize() int64 {
+	if o.MaxEntrySize > 0 {
+		return o.MaxEntrySize
+	}
+	return DefaultMaxEntrySize
+}
+
+// stripComponents removes the first n path elements from name. It
+// returns false if nothing remains.
+func stripComponents(name string, n int) (string, bool) {
+	name = path.Clean(filepath.ToSlash(name))
+	parts := strings.Split(name, "/")
+	if n >= len(parts) {
+		return "", false
+	}
+	name = strings.Join(parts[n:], "/")
+	return name, name != ""
+}
+
+// safeJoin joins dest and name, refusing paths that would escape
+// dest (zip slip).
+func safeJoin(dest string, name string) (string, error) {
+	if filepath.IsAbs(name) || strings.HasPrefix(filepath.ToSlash(name), "/") {
+		return "", errors.Errorf("illegal absolute path in archive: %s", name)
+	}
+	target := filepath.Join(dest, filepath.FromSlash(name))
+	rel, err := filepath.Rel(dest, target)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", errors.Errorf("illegal path in archive: %s", name)
+	}
+	return target, nil
+}
+
+// writeEntry writes the content of an archive entry to a temporary
+// file and moves it into place at target. If the content's digest matches
+// existingHash, the temporary file is discarded and false is returned.
+// Entries larger than the maximum entry size, e.g. decompression bombs,
+// fail.
+func writeEntry(target string, entry archiveEntry, existingHash string, opts Options) (bool, error) {
+	limit := opts.maxEntrySize()
+	if entry.size > limit {
+		return false, errors.Errorf("archive entry %s exceeds the maximum size of %d bytes", entry.name, limit)
+	}
+	reader, err := entry.open()
+	if err != nil {
+		return false, errors.Wrap(err, "error reading archive entry")
+	}
+	defer func() { _ = reader.Close() }()
+
+	file, err := createTemp(target)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = os.Remove(file.Name()) }()
+
+	hash := sha256.New()
+	// The declared size may be wrong, so reading stops past the limit.
+	content := opts.Progress.reader(entry.name, entry.size, io.LimitReader(reader, limit+1))
+	written, err := io.Copy(io.MultiWriter(file, hash), content) //nolint:gosec // G110: limited to limit+1 bytes.
+	if err == nil && written > limit {
+		err = errors.Errorf("archive entry %s exceeds the maximum size of %d bytes", entry.name, limit)
+	}
+	if err != nil {
+		_ = file.Close()
+		return false, errors.Wrap(err, "error extracting file")
+	}
+	if err = file.Close(); err != nil {
+		return false, errors.Wrap(err, "error closing file")
+	}
+	if existingHash != "" && existingHash == hex.EncodeToString(hash.Sum(nil)) {
+		return false, nil
+	}
+
+	if err = os.Chmod(file.Name(), entry.mode.Perm()); err != nil {
+		return false, errors.Wrap(err, "error setting file mode")
+	}
+	if err = os.Chtimes(file.Name(), entry.modTime, entry.modTime); err != nil {
+		return false, errors.Wrap(err, "error setting file times")
+	}
+	if err = opts.Transaction.install(file.Name(), target); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// walkZip calls fn for each regular file in the zip archive at src.
+func walkZip(src string, fn func(archiveEntry) error) error {
+	reader, err := zip.OpenReader(src)
+	if err != nil {
+		return errors.Wrap(err, "error opening zip archive")
+	}
+	defer func() { _ = reader.Close() }()
+
+	for _, file := range reader.File {
+		if !file.Mode().IsRegular() {
+			if file.Mode()&os.ModeSymlink != 0 {
+				log.WithField("entry", file.Name).Warn("skipping symbolic link in archive")
+			}
+			continue
+		}
+		file := file
+		err = fn(archiveEntry{
+			name:    file.Name,
+			mode:    file.Mode(),
+			size:    int64(file.UncompressedSize64),
+			modTime: file.Modified,
+			open:    func() (io.ReadCloser, error) { return file.Open() },
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// walkTarGz calls fn for each regular file in the gzipped tar archive
+// at src.
+func walkTarGz(src string, fn func(archiveEntry) error) error {
+	file, err := os.Open(src)
+	if err != nil {
+		return errors.Wrap(err, "error opening tar archive")
+	}
+	defer func() { _ = file.Close() }()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return errors.Wrap(err, "error reading gzip stream")
+	}
+	defer func() { _ = gz.Close() }()
+
+	reader := tar.NewReader(gz)
+	for {
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrap(err, "error reading tar archive")
+		}
+
+		switch header.Typeflag {
+		case tar.TypeReg:
+			err = fn(archiveEntry{
+				name:    header.Name,
+				mode:    header.FileInfo().Mode(),
+				size:    header.Size,
+				modTime: header.ModTime,
+				open:    func() (io.ReadCloser, error) { return io.NopCloser(reader), nil },
+			})
+			if err != nil {
+				return err
+			}
+		case tar.TypeSymlink, tar.TypeLink:
+			log.WithField("entry", header.Name).Warn("skipping link in archive")
+		}
+	}
+}
+
+// entryInfo adapts an archiveEntry to os.FileInfo for comparisons.
+type entryInfo struct {
+	entry archiveEntry
+}
+
+func (e entryInfo) Name() string       { return path.Base(e.entry.name) }
+func (e entryInfo) Size() int64        { return e.entry.size }
+func (e entryInfo) Mode() os.FileMode  { return e.entry.mode }
+func (e entryInfo) ModTime() time.Time { return e.entry.modTime }
+func (e entryInfo) IsDir() bool        { return false }
+func (e entryInfo) Sys() interface{}   { return nil }
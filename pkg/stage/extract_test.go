@@ -0,0 +1,172 @@
+//
+// Copyright (c) 2024 Quadient Group AG
+//
+// This file is subject to the terms and conditions defined in the
+// 'LICENSE' file found in the root of this source code package.
+//
+
+package stage_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/robertwtucker/spt-util/pkg/stage"
+	"github.com/stretchr/testify/assert"
+)
+
+var archiveFiles = map[string]string{
+	"bundle/foo.txt":       "foo",
+	"bundle/sub/bar.wfd":   "bar",
+	"bundle/sub/baz.txt":   "baz",
+	"bundle/docs/qux.json": "qux",
+}
+
+func writeZip(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+	file, err := os.Create(path)
+	assert.NoError(t, err)
+	defer func() { _ = file.Close() }()
+
+	writer := zip.NewWriter(file)
+	for name, content := range files {
+		entry, err := writer.Create(name)
+		assert.NoError(t, err)
+		_, err = entry.Write([]byte(content))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, writer.Close())
+}
+
+func writeTarGz(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+	file, err := os.Create(path)
+	assert.NoError(t, err)
+	defer func() { _ = file.Close() }()
+
+	gz := gzip.NewWriter(file)
+	writer := tar.NewWriter(gz)
+	for name, content := range files {
+		assert.NoError(t, writer.WriteHeader(&tar.Header{
+			Name:     name,
+			Mode:     0o644,
+			Size:     int64(len(content)),
+			Typeflag: tar.TypeReg,
+		}))
+		_, err = writer.Write([]byte(content))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, writer.Close())
+	assert.NoError(t, gz.Close())
+}
+
+func TestStage_Extract(t *testing.T) {
+	for name, write := range map[string]func(*testing.T, string, map[string]string){
+		"bundle.zip":    writeZip,
+		"bundle.tar.gz": writeTarGz,
+	} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			src := filepath.Join(dir, name)
+			dest := filepath.Join(dir, "dest")
+			write(t, src, archiveFiles)
+
+			result, err := stage.Stage(stage.FilesToCopy{
+				Source:          src,
+				Destination:     dest,
+				Action:          stage.ActionExtract,
+				StripComponents: 1,
+				Include:         []string{"*.txt", "sub/*"},
+				Exclude:         []string{"baz.txt"},
+			}, stage.Options{})
+			assert.NoError(t, err)
+			assert.Len(t, result.Copied, 2)
+
+			content, err := os.ReadFile(filepath.Join(dest, "foo.txt"))
+			assert.NoError(t, err)
+			assert.Equal(t, "foo", string(content))
+			assert.FileExists(t, filepath.Join(dest, "sub", "bar.wfd"))
+			assert.NoFileExists(t, filepath.Join(dest, "sub", "baz.txt"))
+			assert.NoFileExists(t, filepath.Join(dest, "docs", "qux.json"))
+		})
+	}
+}
+
+func TestStage_ExtractSkipUnchanged(t *testing.T) {
+	for _, mode := range []string{stage.CompareModTime, stage.CompareHash} {
+		t.Run(mode, func(t *testing.T) {
+			dir := t.TempDir()
+			src := filepath.Join(dir, "bundle.tgz")
+			writeTarGz(t, src, archiveFiles)
+			entry := stage.FilesToCopy{
+				Source:        src,
+				Destination:   filepath.Join(dir, "dest"),
+				Action:        stage.ActionExtract,
+				SkipUnchanged: true,
+				Compare:       mode,
+			}
+
+			result, err := stage.Stage(entry, stage.Options{})
+			assert.NoError(t, err)
+			assert.Len(t, result.Copied, len(archiveFiles))
+
+			result, err = stage.Stage(entry, stage.Options{})
+			assert.NoError(t, err)
+			assert.Empty(t, result.Copied)
+			assert.Len(t, result.Skipped, len(archiveFiles))
+		})
+	}
+}
+
+func TestStage_ExtractZipSlip(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "evil.zip")
+	writeZip(t, src, map[string]string{"../../evil.txt": "evil"})
+
+	_, err := stage.Stage(stage.FilesToCopy{
+		Source:      src,
+		Destination: filepath.Join(dir, "dest"),
+		Action:      stage.ActionExtract,
+	}, stage.Options{})
+	assert.Error(t, err)
+	assert.NoFileExists(t, filepath.Join(dir, "evil.txt"))
+}
+
+func TestStage_ExtractUnknownFormat(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "bundle.rar")
+	writeFile(t, src, "foo")
+
+	_, err := stage.Stage(stage.FilesToCopy{
+		Source:      src,
+		Destination: filepath.Join(dir, "dest"),
+		Action:      stage.ActionExtract,
+	}, stage.Options{})
+	assert.Error(t, err)
+}
+
+func TestStage_ExtractMaxEntrySize(t *testing.T) {
+	for name, write := range map[string]func(*testing.T, string, map[string]string){
+		"bundle.zip":    writeZip,
+		"bundle.tar.gz": writeTarGz,
+	} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			src := filepath.Join(dir, name)
+			write(t, src, map[string]string{"big.txt": strings.Repeat("x", 100)})
+
+			_, err := stage.Stage(stage.FilesToCopy{
+				Source:      src,
+				Destination: filepath.Join(dir, "dest"),
+				Action:      stage.ActionExtract,
+			}, stage.Options{MaxEntrySize: 10})
+			assert.ErrorContains(t, err, "exceeds the maximum size")
+			assert.NoFileExists(t, filepath.Join(dir, "dest", "big.txt"))
+		})
+	}
+}
@@ -0,0 +1,165 @@
+//
+// Copyright (c) 2024 Quadient Group AG
+//
+// This file is subject to the terms and conditions defined in the
+// 'LICENSE' file found in the root of this source code package.
+//
+
+package stage
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// hasGlob reports whether a path contains glob pattern characters.
+func hasGlob(pattern string) bool {
+	return strings.ContainsAny(pattern, "*?[")
+}
+
+// match reports whether a slash-separated name matches the pattern.
+// In addition to path.Match syntax, a "**" element matches zero or
+// more path elements.
+func match(pattern string, name string) bool {
+	return matchElements(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+// matchElements matches path elements against pattern elements.
+func matchElements(pattern []string, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			if len(pattern) == 1 {
+				return true
+			}
+			for i := 0; i <= len(name); i++ {
+				if matchElements(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// matches reports whether a relative path passes the entry's include
+// and exclude patterns.
+func (f *FilesToCopy) matches(name string) bool {
+	if len(f.Include) > 0 && !matchAny(f.Include, name) {
+		return false
+	}
+	return !matchAny(f.Exclude, name)
+}
+
+// matchAny reports whether name matches any of the patterns. Patterns
+// without a separator are also matched against the base name.
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if match(pattern, name) {
+			return true
+		}
+		if !strings.Contains(pattern, "/") && match(pattern, path.Base(name)) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitGlob splits a glob pattern into the directory preceding its
+// first pattern element and the (slash-separated) remaining pattern.
+func splitGlob(pattern string) (string, string) {
+	elements := strings.Split(filepath.ToSlash(pattern), "/")
+	for i, element := range elements {
+		if hasGlob(element) {
+			base := strings.Join(elements[:i], "/")
+			if base == "" && i > 0 {
+				base = "/"
+			} else if base == "" {
+				base = "."
+			}
+			return filepath.FromSlash(base), strings.Join(elements[i:], "/")
+		}
+	}
+	return filepath.Clean(pattern), ""
+}
+
+// expandGlob returns the files matching the entry's source pattern,
+// mapped to their destination paths.
+func (f *FilesToCopy) expandGlob() (map[string]string, error) {
+	base, pattern := splitGlob(f.Source)
+	return f.expand(base, pattern)
+}
+
+// expand returns the files below base matching the pattern, mapped to
+// their destination paths.
+func (f *FilesToCopy) expand(base string, pattern string) (map[string]string, error) {
+	files := map[string]string{} // src -> dest
+	targets := map[string]string{}
+
+	err := filepath.WalkDir(base, func(src string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !f.globCandidate(src, entry) {
+			return nil
+		}
+		rel, err := filepath.Rel(base, src)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if !match(pattern, rel) || !f.matches(rel) {
+			return nil
+		}
+
+		dest, err := f.target(f.Destination, rel)
+		if err != nil {
+			return err
+		}
+		if other, found := targets[dest]; found {
+			return errors.Errorf("both %s and %s would be staged to %s", other, src, dest)
+		}
+		targets[dest] = src
+		files[src] = dest
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "error expanding source pattern")
+	}
+
+	return files, nil
+}
+
+// globCandidate reports whether a walked directory entry may be staged:
+// regular files and, unless symlinks are skipped, links to them.
+func (f *FilesToCopy) globCandidate(src string, entry fs.DirEntry) bool {
+	if entry.Type().IsRegular() {
+		return true
+	}
+	if entry.Type()&fs.ModeSymlink == 0 || strings.ToLower(f.Symlinks) == SymlinksSkip {
+		return false
+	}
+	info, err := os.Stat(src)
+	return err == nil && info.Mode().IsRegular()
+}
+
+// target returns the path below dest for a file at the (slash
+// separated) relative path, honoring the flatten option.
+func (f *FilesToCopy) target(dest string, rel string) (string, error) {
+	if f.Flatten {
+		rel = path.Base(rel)
+	}
+	return safeJoin(dest, rel)
+}
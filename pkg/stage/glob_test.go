@@ -0,0 +1,122 @@
+//
+// Copyright (c) 2024 Quadient Group AG
+//
+// This file is subject to the terms and conditions defined in the
+// 'LICENSE' file found in the root of this source code package.
+//
+
+package stage_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/robertwtucker/spt-util/pkg/stage"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeBundle(t *testing.T, dir string) {
+	t.Helper()
+	writeFile(t, filepath.Join(dir, "a.wfd"), "a")
+	writeFile(t, filepath.Join(dir, "a.txt"), "a")
+	writeFile(t, filepath.Join(dir, "sub", "b.wfd"), "b")
+	writeFile(t, filepath.Join(dir, "sub", "deep", "c.wfd"), "c")
+	writeFile(t, filepath.Join(dir, "sub", "deep", "c.bak.wfd"), "c")
+}
+
+func TestStage_Glob(t *testing.T) {
+	tests := []struct {
+		name     string
+		entry    stage.FilesToCopy
+		expected []string
+	}{
+		{
+			name:     "single level",
+			entry:    stage.FilesToCopy{Source: "*.wfd"},
+			expected: []string{"a.wfd"},
+		},
+		{
+			name:     "double star",
+			entry:    stage.FilesToCopy{Source: "**/*.wfd"},
+			expected: []string{"a.wfd", "sub/b.wfd", "sub/deep/c.wfd", "sub/deep/c.bak.wfd"},
+		},
+		{
+			name:     "nested double star",
+			entry:    stage.FilesToCopy{Source: "sub/**/*.wfd"},
+			expected: []string{"b.wfd", "deep/c.wfd", "deep/c.bak.wfd"},
+		},
+		{
+			name:     "exclude",
+			entry:    stage.FilesToCopy{Source: "**/*.wfd", Exclude: []string{"*.bak.wfd"}},
+			expected: []string{"a.wfd", "sub/b.wfd", "sub/deep/c.wfd"},
+		},
+		{
+			name:     "include",
+			entry:    stage.FilesToCopy{Source: "**", Include: []string{"sub/**"}, Exclude: []string{"*.bak.wfd"}},
+			expected: []string{"sub/b.wfd", "sub/deep/c.wfd"},
+		},
+		{
+			name:     "flatten",
+			entry:    stage.FilesToCopy{Source: "sub/**/*.wfd", Exclude: []string{"*.bak.wfd"}, Flatten: true},
+			expected: []string{"b.wfd", "c.wfd"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			src := filepath.Join(dir, "src")
+			dest := filepath.Join(dir, "dest")
+			writeBundle(t, src)
+
+			entry := tt.entry
+			entry.Source = filepath.Join(src, entry.Source)
+			entry.Destination = dest
+			result, err := stage.Stage(entry, stage.Options{})
+			assert.NoError(t, err)
+
+			expected := []string{}
+			for _, rel := range tt.expected {
+				expected = append(expected, filepath.Join(dest, filepath.FromSlash(rel)))
+			}
+			assert.ElementsMatch(t, expected, result.Copied)
+			for _, path := range expected {
+				assert.FileExists(t, path)
+			}
+		})
+	}
+}
+
+func TestStage_GlobFlattenConflict(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	writeBundle(t, src)
+	writeFile(t, filepath.Join(src, "sub", "deep", "b.wfd"), "b")
+
+	_, err := stage.Stage(stage.FilesToCopy{
+		Source:      filepath.Join(src, "**", "b.wfd"),
+		Destination: filepath.Join(dir, "dest"),
+		Flatten:     true,
+	}, stage.Options{})
+	assert.Error(t, err)
+}
+
+func TestStage_DirectoryWithFilters(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	dest := filepath.Join(dir, "dest")
+	writeBundle(t, src)
+
+	result, err := stage.Stage(stage.FilesToCopy{
+		Source:      src,
+		Destination: dest,
+		Include:     []string{"*.wfd"},
+		Exclude:     []string{"sub/deep/**"},
+	}, stage.Options{})
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{
+		filepath.Join(dest, "a.wfd"),
+		filepath.Join(dest, "sub", "b.wfd"),
+	}, result.Copied)
+	assert.NoFileExists(t, filepath.Join(dest, "a.txt"))
+}
@@ -0,0 +1,235 @@
+//
+// Copyright (c) 2024 Quadient Group AG
+//
+// This file is subject to the terms and conditions defined in the
+// 'LICENSE' file found in the root of this source code package.
+//
+
+package stage
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// Kinds of drift between a manifest and the file system.
+const (
+	DriftMissing  = "missing"
+	DriftSize     = "size"
+	DriftModified = "modified"
+)
+
+// ManifestFile describes a file written by staging.
+type ManifestFile struct {
+	Path     string    `json:"path"`
+	Source   string    `json:"source"`
+	Size     int64     `json:"size"`
+	SHA256   string    `json:"sha256"`
+	ModTime  time.Time `json:"modTime"`
+	StagedAt time.Time `json:"stagedAt"`
+}
+
+// Manifest records the files written by staging runs so that they can
+// be verified or removed later. Files are keyed by destination path; a
+// file staged again replaces its previous record.
+type Manifest struct {
+	Updated time.Time      `json:"updated"`
+	Files   []ManifestFile `json:"files"`
+	mutex   sync.Mutex
+}
+
+// Drift describes a manifest file that no longer matches the file
+// system.
+type Drift struct {
+	Path    string
+	Kind    string
+	Details string
+}
+
+// LoadManifest reads the manifest at path. A missing manifest yields
+// an empty one.
+func LoadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &Manifest{}, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading manifest")
+	}
+
+	manifest := &Manifest{}
+	if err = json.Unmarshal(data, manifest); err != nil {
+		return nil, errors.Wrap(err, "error parsing manifest")
+	}
+	return manifest, nil
+}
+
+// Record adds the files copied by an entry with the given source.
+func (m *Manifest) Record(source string, result *Result) error {
+	if m == nil || result == nil {
+		return nil
+	}
+
+	files := make([]ManifestFile, 0, len(result.Copied))
+	now := time.Now().UTC()
+	for _, dest := range result.Copied {
+		path, err := filepath.Abs(dest)
+		if err != nil {
+			return errors.Wrap(err, "error resolving destination path")
+		}
+		info, err := os.Lstat(path)
+		if err != nil {
+			return errors.Wrap(err, "error reading staged file info")
+		}
+		file := ManifestFile{
+			Path:     path,
+			Source:   source,
+			Size:     info.Size(),
+			ModTime:  info.ModTime().UTC(),
+			StagedAt: now,
+		}
+		if info.Mode().IsRegular() {
+			if file.SHA256, err = FileSHA256(path); err != nil {
+				return err
+			}
+		}
+		files = append(files, file)
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	for _, file := range files {
+		m.put(file)
+	}
+	return nil
+}
+
+// Select returns a manifest holding the files staged from the given
+// sources.
+func (m *Manifest) Select(sources []string) *Manifest {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	selected := map[string]bool{}
+	for _, source := range sources {
+		selected[source] = true
+	}
+	manifest := &Manifest{Updated: m.Updated, Files: []ManifestFile{}}
+	for _, file := range m.Files {
+		if selected[file.Source] {
+			manifest.Files = append(manifest.Files, file)
+		}
+	}
+	return manifest
+}
+
+// put adds or replaces the record for a file.
+func (m *Manifest) put(file ManifestFile) {
+	for i := range m.Files {
+		if m.Files[i].Path == file.Path {
+			m.Files[i] = file
+			return
+		}
+	}
+	m.Files = append(m.Files, file)
+}
+
+// Save writes the manifest to path, replacing it atomically.
+func (m *Manifest) Save(path string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	sort.Slice(m.Files, func(i, j int) bool { return m.Files[i].Path < m.Files[j].Path })
+	m.Updated = time.Now().UTC()
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "error encoding manifest")
+	}
+
+	if err = os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return errors.Wrap(err, "error creating manifest directory")
+	}
+	partial := path + ".part"
+	if err = os.WriteFile(partial, data, 0o644); err != nil { //nolint:gosec // the manifest is not secret.
+		return errors.Wrap(err, "error writing manifest")
+	}
+	if err = os.Rename(partial, path); err != nil {
+		return errors.Wrap(err, "error replacing manifest")
+	}
+	return nil
+}
+
+// Clean removes the files listed in the manifest and drops them from
+// it. Files that no longer exist are dropped as well; files that
+// cannot be removed are kept. It returns the paths removed.
+func (m *Manifest) Clean() ([]string, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	var removed []string
+	kept := []ManifestFile{}
+	for _, file := range m.Files {
+		err := os.Remove(file.Path)
+		switch {
+		case err == nil:
+			log.WithField("path", file.Path).Debug("removed staged file")
+			removed = append(removed, file.Path)
+		case os.IsNotExist(err):
+			log.WithField("path", file.Path).Warn("staged file already removed")
+		default:
+			log.WithField("path", file.Path).Error("unable to remove staged file: ", err)
+			kept = append(kept, file)
+		}
+	}
+	m.Files = kept
+
+	if len(kept) > 0 {
+		return removed, errors.Errorf("unable to remove %d staged file(s)", len(kept))
+	}
+	return removed, nil
+}
+
+// Verify compares the manifest with the file system and returns the
+// files that are missing or were changed since they were staged.
+func (m *Manifest) Verify() ([]Drift, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	var drift []Drift
+	for _, file := range m.Files {
+		info, err := os.Lstat(file.Path)
+		if os.IsNotExist(err) {
+			drift = append(drift, Drift{Path: file.Path, Kind: DriftMissing})
+			continue
+		}
+		if err != nil {
+			return drift, errors.Wrap(err, "error reading staged file info")
+		}
+		if info.Size() != file.Size {
+			drift = append(drift, Drift{
+				Path:    file.Path,
+				Kind:    DriftSize,
+				Details: FormatBytes(file.Size) + " staged, " + FormatBytes(info.Size()) + " found",
+			})
+			continue
+		}
+		if file.SHA256 == "" || !info.Mode().IsRegular() {
+			continue
+		}
+		var hash string
+		if hash, err = FileSHA256(file.Path); err != nil {
+			return drift, err
+		}
+		if hash != file.SHA256 {
+			drift = append(drift, Drift{Path: file.Path, Kind: DriftModified, Details: "content changed"})
+		}
+	}
+	return drift, nil
+}
@@ -0,0 +1,107 @@
+//
+// Copyright (c) 2024 Quadient Group AG
+//
+// This file is subject to the terms and conditions defined in the
+// 'LICENSE' file found in the root of this source code package.
+//
+
+package stage_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/robertwtucker/spt-util/pkg/stage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManifest(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	dest := filepath.Join(dir, "dest")
+	writeFile(t, filepath.Join(src, "foo.txt"), "foo")
+	writeFile(t, filepath.Join(src, "sub", "bar.txt"), "bar")
+	writeFile(t, filepath.Join(dest, "unrelated.txt"), "unrelated")
+	path := filepath.Join(dir, "state", "manifest.json")
+
+	manifest, err := stage.LoadManifest(path)
+	require.NoError(t, err)
+	result, err := stage.Stage(stage.FilesToCopy{Source: src, Destination: dest}, stage.Options{})
+	require.NoError(t, err)
+	require.NoError(t, manifest.Record(src, result))
+	require.NoError(t, manifest.Save(path))
+
+	manifest, err = stage.LoadManifest(path)
+	require.NoError(t, err)
+	require.Len(t, manifest.Files, 2)
+	foo := manifest.Files[0]
+	assert.Equal(t, filepath.Join(dest, "foo.txt"), foo.Path)
+	assert.Equal(t, src, foo.Source)
+	assert.Equal(t, int64(3), foo.Size)
+	assert.Equal(t, fooSHA256, foo.SHA256)
+	assert.False(t, foo.StagedAt.IsZero())
+
+	drift, err := manifest.Verify()
+	require.NoError(t, err)
+	assert.Empty(t, drift)
+
+	writeFile(t, filepath.Join(dest, "foo.txt"), "FOO")
+	require.NoError(t, os.Remove(filepath.Join(dest, "sub", "bar.txt")))
+	drift, err = manifest.Verify()
+	require.NoError(t, err)
+	assert.Equal(t, []stage.Drift{
+		{Path: filepath.Join(dest, "foo.txt"), Kind: stage.DriftModified, Details: "content changed"},
+		{Path: filepath.Join(dest, "sub", "bar.txt"), Kind: stage.DriftMissing},
+	}, drift)
+
+	removed, err := manifest.Clean()
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dest, "foo.txt")}, removed)
+	assert.Empty(t, manifest.Files)
+	assert.NoFileExists(t, filepath.Join(dest, "foo.txt"))
+	assert.FileExists(t, filepath.Join(dest, "unrelated.txt"))
+}
+
+func TestManifest_Restage(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "foo.txt")
+	dest := filepath.Join(dir, "dest", "foo.txt")
+	writeFile(t, src, "foo")
+
+	manifest, err := stage.LoadManifest(filepath.Join(dir, "missing.json"))
+	require.NoError(t, err)
+	assert.Empty(t, manifest.Files)
+
+	for _, content := range []string{"foo", "foobar"} {
+		writeFile(t, src, content)
+		result, stageErr := stage.Stage(stage.FilesToCopy{Source: src, Destination: dest}, stage.Options{})
+		require.NoError(t, stageErr)
+		require.NoError(t, manifest.Record(src, result))
+	}
+	require.Len(t, manifest.Files, 1)
+	assert.Equal(t, int64(6), manifest.Files[0].Size)
+}
+
+func TestManifest_Select(t *testing.T) {
+	dir := t.TempDir()
+	manifest, err := stage.LoadManifest(filepath.Join(dir, "missing.json"))
+	require.NoError(t, err)
+	for _, name := range []string{"foo.txt", "bar.txt"} {
+		src := filepath.Join(dir, name)
+		writeFile(t, src, name)
+		result, stageErr := stage.Stage(stage.FilesToCopy{Source: src, Destination: filepath.Join(dir, "dest", name)},
+			stage.Options{})
+		require.NoError(t, stageErr)
+		require.NoError(t, manifest.Record(src, result))
+	}
+	require.NoError(t, os.Remove(filepath.Join(dir, "dest", "bar.txt")))
+
+	selected := manifest.Select([]string{filepath.Join(dir, "foo.txt")})
+	require.Len(t, selected.Files, 1)
+	drift, err := selected.Verify()
+	require.NoError(t, err)
+	assert.Empty(t, drift)
+	assert.Empty(t, manifest.Select(nil).Files)
+}
@@ -0,0 +1,185 @@
+//
+// Copyright (c) 2024 Quadient Group AG
+//
+// This file is subject to the terms and conditions defined in the
+// 'LICENSE' file found in the root of this source code package.
+//
+
+package stage
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	cp "github.com/otiai10/copy"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// Overwrite policies for existing destination files.
+const (
+	OverwriteAlways  = "always"
+	OverwriteNever   = "never"
+	OverwriteIfNewer = "if-newer"
+)
+
+// Symbolic link handling modes.
+const (
+	SymlinksShallow = "shallow"
+	SymlinksDeep    = "deep"
+	SymlinksSkip    = "skip"
+)
+
+// validate checks the entry's options for unsupported values.
+func (f *FilesToCopy) validate() error {
+	switch strings.ToLower(f.Overwrite) {
+	case OverwriteAlways, OverwriteNever, OverwriteIfNewer, "":
+	default:
+		return errors.Errorf("unsupported overwrite policy: %s", f.Overwrite)
+	}
+	switch strings.ToLower(f.Symlinks) {
+	case SymlinksShallow, SymlinksDeep, SymlinksSkip, "":
+	default:
+		return errors.Errorf("unsupported symlink handling: %s", f.Symlinks)
+	}
+	if _, err := parseMode(f.FileMode); err != nil {
+		return errors.Wrap(err, "invalid fileMode")
+	}
+	if _, err := parseMode(f.DirMode); err != nil {
+		return errors.Wrap(err, "invalid dirMode")
+	}
+	if f.Template && (f.SHA256 != "" || f.Verify) {
+		return errors.Errorf("checksums cannot be used with template entry: %s", f.Source)
+	}
+	if f.Template && strings.ToLower(f.Action) == ActionExtract {
+		return errors.Errorf("templates cannot be used with extract action: %s", f.Source)
+	}
+	return nil
+}
+
+// parseMode parses an octal permission string such as "0640". An
+// empty string yields a zero mode.
+func parseMode(mode string) (os.FileMode, error) {
+	if mode == "" {
+		return 0, nil
+	}
+	value, err := strconv.ParseUint(mode, 8, 32)
+	if err != nil || value > 0o7777 {
+		return 0, errors.Errorf("invalid octal mode: %s", mode)
+	}
+	return os.FileMode(value), nil
+}
+
+// copyOptions maps the entry's options onto the copy package options.
+func (f *FilesToCopy) copyOptions() cp.Options {
+	opts := cp.Options{
+		PreserveTimes: f.SkipUnchanged,
+		OnSymlink: func(string) cp.SymlinkAction {
+			switch strings.ToLower(f.Symlinks) {
+			case SymlinksDeep:
+				return cp.Deep
+			case SymlinksSkip:
+				return cp.Skip
+			default:
+				return cp.Shallow
+			}
+		},
+	}
+	if f.FileMode != "" || f.DirMode != "" {
+		// Explicit modes are applied once the content has been copied.
+		opts.PermissionControl = cp.DoNothing
+	}
+	return opts
+}
+
+// overwriteSkip reports whether the overwrite policy prevents copying
+// a source (described by srcInfo) to dest.
+func (f *FilesToCopy) overwriteSkip(srcInfo os.FileInfo, dest string) (bool, error) {
+	policy := strings.ToLower(f.Overwrite)
+	if policy == OverwriteAlways || policy == "" {
+		return false, nil
+	}
+
+	destInfo, err := os.Lstat(dest)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, errors.Wrap(err, "error reading destination file info")
+	}
+	if policy == OverwriteIfNewer && srcInfo.ModTime().After(destInfo.ModTime()) {
+		return false, nil
+	}
+
+	log.WithFields(log.Fields{
+		"dest":      dest,
+		"overwrite": policy,
+	}).Debug("skipping existing file")
+	return true, nil
+}
+
+// applyAttributes sets the configured mode and ownership on a staged
+// file or directory.
+func (f *FilesToCopy) applyAttributes(path string, isDir bool) error {
+	modeSetting := f.FileMode
+	if isDir {
+		modeSetting = f.DirMode
+	}
+	if modeSetting != "" {
+		mode, err := parseMode(modeSetting)
+		if err != nil {
+			return err
+		}
+		if err = os.Chmod(path, mode); err != nil {
+			return errors.Wrap(err, "error setting mode")
+		}
+	}
+
+	if f.UID != nil || f.GID != nil {
+		uid, gid := -1, -1
+		if f.UID != nil {
+			uid = *f.UID
+		}
+		if f.GID != nil {
+			gid = *f.GID
+		}
+		if err := os.Lchown(path, uid, gid); err != nil {
+			return errors.Wrap(err, "error setting ownership")
+		}
+	}
+	return nil
+}
+
+// hasAttributes reports whether any mode or ownership is configured.
+func (f *FilesToCopy) hasAttributes() bool {
+	return f.FileMode != "" || f.DirMode != "" || f.UID != nil || f.GID != nil
+}
+
+// mkdirAll creates dir and any missing parents, applying the
+// configured directory mode and ownership to the ones it created and
+// recording them in the (optional) transaction.
+func (f *FilesToCopy) mkdirAll(dir string, tx *Transaction) error {
+	missing := []string{}
+	for current := filepath.Clean(dir); ; current = filepath.Dir(current) {
+		if _, err := os.Stat(current); err == nil {
+			break
+		}
+		missing = append(missing, current)
+		if filepath.Dir(current) == current {
+			break
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return errors.Wrap(err, "error creating directory")
+	}
+	for i := len(missing) - 1; i >= 0; i-- {
+		tx.createdDir(missing[i])
+		if err := f.applyAttributes(missing[i], true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
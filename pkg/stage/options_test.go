@@ -0,0 +1,160 @@
+//
+// Copyright (c) 2024 Quadient Group AG
+//
+// This file is subject to the terms and conditions defined in the
+// 'LICENSE' file found in the root of this source code package.
+//
+
+package stage_test
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/robertwtucker/spt-util/pkg/stage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStage_Modes(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file modes are not supported on windows")
+	}
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	dest := filepath.Join(dir, "dest")
+	writeFile(t, filepath.Join(src, "sub", "foo.txt"), "foo")
+	uid, gid := os.Getuid(), os.Getgid()
+
+	_, err := stage.Stage(stage.FilesToCopy{
+		Source:      src,
+		Destination: dest,
+		FileMode:    "0640",
+		DirMode:     "0750",
+		UID:         &uid,
+		GID:         &gid,
+	}, stage.Options{})
+	assert.NoError(t, err)
+
+	info, err := os.Stat(filepath.Join(dest, "sub", "foo.txt"))
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o640), info.Mode().Perm())
+	info, err = os.Stat(filepath.Join(dest, "sub"))
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o750), info.Mode().Perm())
+}
+
+func TestStage_InvalidOptions(t *testing.T) {
+	for name, entry := range map[string]stage.FilesToCopy{
+		"file mode": {FileMode: "rw-r--r--"},
+		"dir mode":  {DirMode: "99"},
+		"overwrite": {Overwrite: "sometimes"},
+		"symlinks":  {Symlinks: "hard"},
+		"action":    {Action: "move"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			entry.Source = t.TempDir()
+			entry.Destination = t.TempDir()
+			_, err := stage.Stage(entry, stage.Options{})
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestStage_OverwriteNever(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "foo.txt")
+	dest := filepath.Join(dir, "dest.txt")
+	writeFile(t, src, "foo")
+	writeFile(t, dest, "bar")
+
+	result, err := stage.Stage(stage.FilesToCopy{Source: src, Destination: dest, Overwrite: stage.OverwriteNever}, stage.Options{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{dest}, result.Skipped)
+
+	content, err := os.ReadFile(dest)
+	assert.NoError(t, err)
+	assert.Equal(t, "bar", string(content))
+}
+
+func TestStage_OverwriteIfNewer(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	dest := filepath.Join(dir, "dest")
+	writeFile(t, filepath.Join(src, "old.txt"), "old")
+	writeFile(t, filepath.Join(src, "new.txt"), "new")
+	writeFile(t, filepath.Join(dest, "old.txt"), "keep")
+	writeFile(t, filepath.Join(dest, "new.txt"), "replace")
+
+	past := time.Now().Add(-time.Hour)
+	assert.NoError(t, os.Chtimes(filepath.Join(src, "old.txt"), past, past))
+	assert.NoError(t, os.Chtimes(filepath.Join(dest, "new.txt"), past, past))
+
+	result, err := stage.Stage(stage.FilesToCopy{Source: src, Destination: dest, Overwrite: stage.OverwriteIfNewer}, stage.Options{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dest, "new.txt")}, result.Copied)
+	assert.Equal(t, []string{filepath.Join(dest, "old.txt")}, result.Skipped)
+}
+
+func TestStage_Symlinks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symbolic links require elevated privileges on windows")
+	}
+	for _, mode := range []string{stage.SymlinksShallow, stage.SymlinksDeep, stage.SymlinksSkip} {
+		t.Run(mode, func(t *testing.T) {
+			dir := t.TempDir()
+			src := filepath.Join(dir, "src")
+			dest := filepath.Join(dir, "dest")
+			writeFile(t, filepath.Join(dir, "target.txt"), "foo")
+			writeFile(t, filepath.Join(src, "foo.txt"), "foo")
+			assert.NoError(t, os.Symlink(filepath.Join(dir, "target.txt"), filepath.Join(src, "link.txt")))
+
+			_, err := stage.Stage(stage.FilesToCopy{Source: src, Destination: dest, Symlinks: mode}, stage.Options{})
+			assert.NoError(t, err)
+
+			info, err := os.Lstat(filepath.Join(dest, "link.txt"))
+			switch mode {
+			case stage.SymlinksShallow:
+				assert.NoError(t, err)
+				assert.NotZero(t, info.Mode()&os.ModeSymlink)
+			case stage.SymlinksDeep:
+				assert.NoError(t, err)
+				assert.True(t, info.Mode().IsRegular())
+			case stage.SymlinksSkip:
+				assert.True(t, os.IsNotExist(err))
+			}
+		})
+	}
+}
+
+func TestStage_SymlinksFiltered(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symbolic links require elevated privileges on windows")
+	}
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	dest := filepath.Join(dir, "dest")
+	writeFile(t, filepath.Join(dir, "target.txt"), "foo")
+	writeFile(t, filepath.Join(src, "foo.txt"), "foo")
+	assert.NoError(t, os.Symlink(filepath.Join(dir, "target.txt"), filepath.Join(src, "excluded.lnk")))
+	assert.NoError(t, os.Symlink(filepath.Join(dir, "target.txt"), filepath.Join(src, "kept.txt")))
+	assert.NoError(t, os.MkdirAll(dest, 0o755))
+	assert.NoError(t, os.Symlink(filepath.Join(dir, "other.txt"), filepath.Join(dest, "kept.txt")))
+
+	_, err := stage.Stage(stage.FilesToCopy{
+		Source:      src,
+		Destination: dest,
+		Exclude:     []string{"*.lnk"},
+		Overwrite:   stage.OverwriteNever,
+	}, stage.Options{})
+	assert.NoError(t, err)
+
+	_, err = os.Lstat(filepath.Join(dest, "excluded.lnk"))
+	assert.True(t, os.IsNotExist(err))
+	target, err := os.Readlink(filepath.Join(dest, "kept.txt"))
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "other.txt"), target)
+	assert.FileExists(t, filepath.Join(dest, "foo.txt"))
+}
@@ -0,0 +1,241 @@
+//
+// Copyright (c) 2024 Quadient Group AG
+//
+// This file is subject to the terms and conditions defined in the
+// 'LICENSE' file found in the root of this source code package.
+//
+
+package stage
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// LargeFileSize is the size above which a file's individual progress
+// is reported.
+const LargeFileSize = 16 << 20
+
+// progressBarWidth is the number of characters in the TTY progress bar.
+const progressBarWidth = 30
+
+// Progress counts the bytes staged across all entries and periodically
+// reports the progress of large files, either as log lines or, when
+// writing to a terminal, as a progress bar. Bytes downloaded from remote
+// sources are counted apart, as they are staged once they are copied
+// out of the cache. A nil Progress records nothing.
+type Progress struct {
+	bytes      int64
+	downloaded int64
+	start      time.Time
+	interval   time.Duration
+	out        *os.File
+	tty        bool
+	files      map[*progressReader]struct{}
+	mutex      sync.Mutex
+	stop       chan struct{}
+	stopped    sync.WaitGroup
+}
+
+// Summary describes the total amount of data staged.
+type Summary struct {
+	Bytes      int64 // bytes written to the destinations
+	Downloaded int64 // bytes downloaded from remote sources
+	Duration   time.Duration
+	Throughput float64 // bytes staged per second
+}
+
+// NewProgress creates a Progress that reports to out every interval.
+func NewProgress(out *os.File, interval time.Duration) *Progress {
+	tty := false
+	if info, err := out.Stat(); err == nil {
+		tty = info.Mode()&os.ModeCharDevice != 0
+	}
+	return &Progress{
+		interval: interval,
+		out:      out,
+		tty:      tty,
+		files:    map[*progressReader]struct{}{},
+	}
+}
+
+// Start begins periodic progress reporting.
+func (p *Progress) Start() {
+	if p == nil {
+		return
+	}
+	p.start = time.Now()
+	p.stop = make(chan struct{})
+	p.stopped.Add(1)
+
+	go func() {
+		defer p.stopped.Done()
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				p.report()
+			case <-p.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop ends progress reporting and returns a summary of the data staged.
+func (p *Progress) Stop() Summary {
+	if p == nil {
+		return Summary{}
+	}
+	if p.stop != nil {
+		close(p.stop)
+		p.stopped.Wait()
+		p.stop = nil
+	}
+	if p.tty {
+		_, _ = fmt.Fprintln(p.out)
+	}
+
+	summary := Summary{
+		Bytes:      atomic.LoadInt64(&p.bytes),
+		Downloaded: atomic.LoadInt64(&p.downloaded),
+		Duration:   time.Since(p.start),
+	}
+	if seconds := summary.Duration.Seconds(); seconds > 0 {
+		summary.Throughput = float64(summary.Bytes) / seconds
+	}
+	return summary
+}
+
+// reader wraps r to count the bytes staged from a file of the given
+// size.
+func (p *Progress) reader(name string, size int64, r io.Reader) io.Reader {
+	if p == nil {
+		return r
+	}
+	return p.track(name, size, r, &p.bytes)
+}
+
+// downloadReader wraps r to count the bytes downloaded from a remote
+// file of the given size.
+func (p *Progress) downloadReader(name string, size int64, r io.Reader) io.Reader {
+	if p == nil {
+		return r
+	}
+	return p.track(name, size, r, &p.downloaded)
+}
+
+// track wraps r to add the bytes read to counter, reporting the
+// progress of large files.
+func (p *Progress) track(name string, size int64, r io.Reader, counter *int64) io.Reader {
+	reader := &progressReader{progress: p, counter: counter, name: filepath.Base(name), size: size, reader: r}
+	if size >= LargeFileSize {
+		p.mutex.Lock()
+		p.files[reader] = struct{}{}
+		p.mutex.Unlock()
+	}
+	return reader
+}
+
+// report writes the progress of the large files currently in flight.
+func (p *Progress) report() {
+	p.mutex.Lock()
+	readers := make([]*progressReader, 0, len(p.files))
+	for reader := range p.files {
+		readers = append(readers, reader)
+	}
+	p.mutex.Unlock()
+	sort.Slice(readers, func(i, j int) bool { return readers[i].name < readers[j].name })
+
+	elapsed := time.Since(p.start).Seconds()
+	total := atomic.LoadInt64(&p.bytes)
+	if !p.tty {
+		for _, reader := range readers {
+			read := atomic.LoadInt64(&reader.read)
+			log.WithFields(log.Fields{
+				"file":    reader.name,
+				"bytes":   read,
+				"size":    reader.size,
+				"percent": fmt.Sprintf("%.1f", percent(read, reader.size)),
+			}).Info("staging progress")
+		}
+		return
+	}
+
+	var read, size int64
+	names := []string{}
+	for _, reader := range readers {
+		read += atomic.LoadInt64(&reader.read)
+		size += reader.size
+		names = append(names, reader.name)
+	}
+	filled := int(percent(read, size) / 100 * progressBarWidth)
+	_, _ = fmt.Fprintf(p.out, "\r[%s%s] %5.1f%% %s %s/s %s\033[K",
+		strings.Repeat("=", filled),
+		strings.Repeat(" ", progressBarWidth-filled),
+		percent(read, size),
+		FormatBytes(total),
+		FormatBytes(int64(float64(total)/elapsed)),
+		strings.Join(names, ", "),
+	)
+}
+
+// done removes a finished reader from the in-flight files.
+func (p *Progress) done(reader *progressReader) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	delete(p.files, reader)
+}
+
+// progressReader counts the bytes read from a file.
+type progressReader struct {
+	progress *Progress
+	counter  *int64 // the Progress total the bytes add to
+	name     string
+	size     int64
+	read     int64
+	reader   io.Reader
+}
+
+// Read implements io.Reader.
+func (r *progressReader) Read(buf []byte) (int, error) {
+	n, err := r.reader.Read(buf)
+	atomic.AddInt64(&r.read, int64(n))
+	atomic.AddInt64(r.counter, int64(n))
+	if err != nil {
+		r.progress.done(r)
+	}
+	return n, err
+}
+
+// percent returns part as a percentage of whole.
+func percent(part int64, whole int64) float64 {
+	if whole <= 0 {
+		return 100
+	}
+	return float64(part) / float64(whole) * 100
+}
+
+// FormatBytes formats a byte count using binary units.
+func FormatBytes(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
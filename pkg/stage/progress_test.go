@@ -0,0 +1,84 @@
+//
+// Copyright (c) 2024 Quadient Group AG
+//
+// This file is subject to the terms and conditions defined in the
+// 'LICENSE' file found in the root of this source code package.
+//
+
+package stage_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/robertwtucker/spt-util/pkg/stage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgress_Summary(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	writeFile(t, filepath.Join(src, "foo.txt"), "foo")
+	writeFile(t, filepath.Join(src, "sub", "bar.txt"), strings.Repeat("bar", 100))
+	writeZip(t, filepath.Join(dir, "bundle.zip"), archiveFiles)
+
+	out, err := os.Create(filepath.Join(dir, "progress.log"))
+	assert.NoError(t, err)
+	defer func() { _ = out.Close() }()
+
+	progress := stage.NewProgress(out, time.Millisecond)
+	progress.Start()
+	opts := stage.Options{Progress: progress}
+	_, err = stage.Stage(stage.FilesToCopy{Source: src, Destination: filepath.Join(dir, "dest")}, opts)
+	assert.NoError(t, err)
+	_, err = stage.Stage(stage.FilesToCopy{
+		Source:      filepath.Join(dir, "bundle.zip"),
+		Destination: filepath.Join(dir, "extracted"),
+		Action:      stage.ActionExtract,
+	}, opts)
+	assert.NoError(t, err)
+	summary := progress.Stop()
+
+	assert.Equal(t, int64(3+300+12), summary.Bytes)
+	assert.Positive(t, summary.Duration)
+}
+
+func TestProgress_RemoteSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "foo.txt", time.Now(), strings.NewReader("foo"))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	progress := stage.NewProgress(os.Stderr, time.Hour)
+	progress.Start()
+	_, err := stage.Stage(stage.FilesToCopy{
+		Source:      server.URL + "/foo.txt",
+		Destination: filepath.Join(dir, "dest", "foo.txt"),
+	}, stage.Options{CacheDir: filepath.Join(dir, "cache"), Progress: progress})
+	assert.NoError(t, err)
+	summary := progress.Stop()
+
+	// The download is not counted as staged a second time.
+	assert.Equal(t, int64(3), summary.Bytes)
+	assert.Equal(t, int64(3), summary.Downloaded)
+}
+
+func TestProgress_Nil(t *testing.T) {
+	var progress *stage.Progress
+	progress.Start()
+
+	assert.Equal(t, stage.Summary{}, progress.Stop())
+}
+
+func TestFormatBytes(t *testing.T) {
+	assert.Equal(t, "512 B", stage.FormatBytes(512))
+	assert.Equal(t, "1.5 KiB", stage.FormatBytes(1536))
+	assert.Equal(t, "16.0 MiB", stage.FormatBytes(stage.LargeFileSize))
+	assert.Equal(t, "2.0 GiB", stage.FormatBytes(2<<30))
+}
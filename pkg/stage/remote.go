@@ -0,0 +1,232 @@
+//
+// Copyright (c) 2024 Quadient Group AG
+//
+// This file is subject to the terms and conditions defined in the
+// 'LICENSE' file found in the root of this source code package.
+//
+
+package stage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-http-utils/headers"
+	"github.com/pkg/errors"
+	"github.com/robertwtucker/spt-util/pkg/constants"
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultDownloadTimeout limits the download of a remote source when
+// no timeout is configured.
+const DefaultDownloadTimeout = 10 * time.Minute
+
+// isRemote reports whether a source refers to a remote location.
+func isRemote(src string) bool {
+	lower := strings.ToLower(src)
+	return strings.HasPrefix(lower, "http://") ||
+		strings.HasPrefix(lower, "https://") ||
+		strings.HasPrefix(lower, "s3://")
+}
+
+// cacheDir returns the directory used for downloaded files.
+func (o *Options) cacheDir() string {
+	if o.CacheDir != "" {
+		return o.CacheDir
+	}
+	return filepath.Join(os.TempDir(), constants.AppName, "cache")
+}
+
+// downloadTimeout returns the time limit of a download.
+func (o *Options) downloadTimeout() time.Duration {
+	if o.DownloadTimeout > 0 {
+		return o.DownloadTimeout
+	}
+	return DefaultDownloadTimeout
+}
+
+// download fetches a remote source into the cache directory and
+// returns the local path. Interrupted downloads are resumed if the
+// result can be verified against the expected checksum, and a cached
+// file matching it is reused.
+func (o *Options) download(src string, expected string) (string, error) {
+	request, err := newRemoteRequest(src)
+	if err != nil {
+		return "", err
+	}
+
+	dir := o.cacheDir()
+	if err = os.MkdirAll(dir, 0o755); err != nil {
+		return "", errors.Wrap(err, "error creating cache directory")
+	}
+	key := sha256.Sum256([]byte(src))
+	local := filepath.Join(dir, hex.EncodeToString(key[:8])+"-"+path.Base(request.URL.Path))
+
+	if expected != "" {
+		if err = verifyChecksum(local, expected); err == nil {
+			log.WithFields(log.Fields{
+				"src":   src,
+				"cache": local,
+			}).Debug("using cached download")
+			return local, nil
+		}
+	}
+
+	log.WithFields(log.Fields{
+		"src":   src,
+		"cache": local,
+	}).Info("downloading remote source")
+	partial := local + ".part"
+	client := &http.Client{Timeout: o.downloadTimeout()}
+	if err = fetch(client, request, partial, expected != "", o.Progress); err != nil {
+		return "", err
+	}
+	if expected != "" {
+		if err = verifyChecksum(partial, expected); err != nil {
+			_ = os.Remove(partial)
+			_ = os.Remove(validatorFile(partial))
+			return "", err
+		}
+	}
+	if err = os.Rename(partial, local); err != nil {
+		return "", errors.Wrap(err, "error moving downloaded file")
+	}
+	_ = os.Remove(validatorFile(partial))
+
+	return local, nil
+}
+
+// newRemoteRequest creates an authenticated GET request for an HTTP(S)
+// or S3 source.
+func newRemoteRequest(src string) (*http.Request, error) {
+	location, err := url.Parse(src)
+	if err != nil {
+		return nil, errors.Wrap(err, "error parsing remote source")
+	}
+
+	switch strings.ToLower(location.Scheme) {
+	case "s3":
+		return newS3Request(location)
+	case "http", "https":
+		request, err := http.NewRequestWithContext(context.Background(), http.MethodGet, src, nil)
+		if err != nil {
+			return nil, errors.Wrap(err, "error creating download request")
+		}
+		if token := os.Getenv(constants.StageHTTPTokenEnv); token != "" {
+			request.Header.Set(headers.Authorization, "Bearer "+token)
+		} else if user := os.Getenv(constants.StageHTTPUserEnv); user != "" {
+			request.SetBasicAuth(user, os.Getenv(constants.StageHTTPPasswordEnv))
+		}
+		return request, nil
+	default:
+		return nil, errors.Errorf("unsupported remote source: %s", src)
+	}
+}
+
+// fetch downloads the request's content to path. If resume is set, an
+// existing (partial) file is resumed from its end, provided the server
+// supports ranges and confirms through If-Range that the content has
+// not changed since the file was started; otherwise the content is
+// downloaded from scratch.
+func fetch(client *http.Client, request *http.Request, path string, resume bool, progress *Progress) error {
+	var offset int64
+	if info, err := os.Stat(path); err == nil && resume {
+		offset = info.Size()
+	}
+	if offset > 0 {
+		//nolint:gosec // the validator file is kept next to the partial download.
+		validator, err := os.ReadFile(validatorFile(path))
+		if err == nil && len(validator) > 0 {
+			request.Header.Set(headers.Range, fmt.Sprintf("bytes=%d-", offset))
+			request.Header.Set(headers.IfRange, string(validator))
+		} else {
+			offset = 0
+		}
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return errors.Wrap(err, "error sending download request")
+	}
+	defer func() { _ = response.Body.Close() }()
+
+	flags := os.O_CREATE | os.O_WRONLY
+	switch {
+	case response.StatusCode == http.StatusPartialContent && offset > 0:
+		log.WithField("offset", offset).Info("resuming download")
+		flags |= os.O_APPEND
+	case response.StatusCode == http.StatusOK:
+		offset = 0
+		flags |= os.O_TRUNC
+		if err = saveValidator(path, response.Header); err != nil {
+			return err
+		}
+	case response.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		// The partial file does not match the content (any more).
+		log.WithField("offset", offset).Info("restarting download")
+		_ = response.Body.Close()
+		if err = os.Remove(path); err != nil {
+			return errors.Wrap(err, "error removing partial download")
+		}
+		request.Header.Del(headers.Range)
+		request.Header.Del(headers.IfRange)
+		return fetch(client, request, path, false, progress)
+	default:
+		body, _ := io.ReadAll(io.LimitReader(response.Body, 1024))
+		return errors.Errorf(
+			"received non-ok HTTP status downloading %s: [%d]:%s",
+			request.URL.Redacted(),
+			response.StatusCode,
+			string(body),
+		)
+	}
+
+	file, err := os.OpenFile(path, flags, 0o644)
+	if err != nil {
+		return errors.Wrap(err, "error creating download file")
+	}
+	body := progress.downloadReader(request.URL.Path, offset+response.ContentLength, response.Body)
+	if _, err = io.Copy(file, body); err != nil {
+		_ = file.Close()
+		return errors.Wrap(err, "error downloading file")
+	}
+	if err = file.Close(); err != nil {
+		return errors.Wrap(err, "error closing download file")
+	}
+
+	return nil
+}
+
+// validatorFile returns the file holding the validator (ETag or
+// Last-Modified) of the content of a partial download.
+func validatorFile(path string) string {
+	return path + ".validator"
+}
+
+// saveValidator records the validator of the content downloaded to
+// path, so that the download can be resumed with If-Range. Weak ETags
+// cannot be used with If-Range, in which case Last-Modified is used.
+func saveValidator(path string, header http.Header) error {
+	validator := header.Get(headers.ETag)
+	if validator == "" || strings.HasPrefix(validator, "W/") {
+		validator = header.Get(headers.LastModified)
+	}
+	if validator == "" {
+		_ = os.Remove(validatorFile(path))
+		return nil
+	}
+	if err := os.WriteFile(validatorFile(path), []byte(validator), 0o644); err != nil {
+		return errors.Wrap(err, "error writing download validator")
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+//
+// Copyright (c) 2024 Quadient Group AG
+//
+// This file is subject to the terms and conditions defined in the
+// 'LICENSE' file found in the root of this source code package.
+//
+
+package stage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-http-utils/headers"
+	"github.com/pkg/errors"
+)
+
+// Environment variables used to access S3-compatible object storage.
+const (
+	s3AccessKeyEnv    = "AWS_ACCESS_KEY_ID"
+	s3SecretKeyEnv    = "AWS_SECRET_ACCESS_KEY"
+	s3SessionTokenEnv = "AWS_SESSION_TOKEN"
+	s3RegionEnv       = "AWS_REGION"
+	s3EndpointEnv     = "AWS_ENDPOINT_URL_S3"
+	s3EndpointAltEnv  = "AWS_ENDPOINT_URL"
+)
+
+// emptyPayloadHash is the SHA-256 digest of an empty request body.
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// s3Credentials holds the keys used to sign S3 requests.
+type s3Credentials struct {
+	AccessKey    string
+	SecretKey    string
+	SessionToken string
+	Region       string
+}
+
+// newS3Request creates a GET request for an s3://bucket/key source.
+// Requests go to the endpoint in AWS_ENDPOINT_URL_S3 (or
+// AWS_ENDPOINT_URL) using path-style addressing, as used by MinIO and
+// other S3-compatible stores, or to AWS itself. Requests are signed
+// when credentials are present in the environment.
+func newS3Request(location *url.URL) (*http.Request, error) {
+	bucket := location.Host
+	key := strings.TrimPrefix(location.Path, "/")
+	if bucket == "" || key == "" {
+		return nil, errors.Errorf("invalid S3 source: %s", location.String())
+	}
+
+	creds := s3Credentials{
+		AccessKey:    os.Getenv(s3AccessKeyEnv),
+		SecretKey:    os.Getenv(s3SecretKeyEnv),
+		SessionToken: os.Getenv(s3SessionTokenEnv),
+		Region:       os.Getenv(s3RegionEnv),
+	}
+	if creds.Region == "" {
+		creds.Region = "us-east-1"
+	}
+
+	var target string
+	endpoint := os.Getenv(s3EndpointEnv)
+	if endpoint == "" {
+		endpoint = os.Getenv(s3EndpointAltEnv)
+	}
+	if endpoint != "" {
+		target = fmt.Sprintf("%s/%s/%s", strings.TrimSuffix(endpoint, "/"), bucket, key)
+	} else {
+		target = fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", bucket, creds.Region, key)
+	}
+
+	request, err := http.NewRequestWithContext(context.Background(), http.MethodGet, target, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating S3 request")
+	}
+	if creds.AccessKey != "" {
+		creds.sign(request, time.Now().UTC())
+	}
+
+	return request, nil
+}
+
+// sign adds an AWS Signature Version 4 Authorization header to a
+// request without a body. Headers already set on the request are
+// included in the signature.
+func (c *s3Credentials) sign(request *http.Request, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	request.Header.Set("X-Amz-Date", amzDate)
+	request.Header.Set("X-Amz-Content-Sha256", emptyPayloadHash)
+	if c.SessionToken != "" {
+		request.Header.Set("X-Amz-Security-Token", c.SessionToken)
+	}
+
+	// Canonical headers (lowercase, sorted) including the host.
+	signed := map[string]string{"host": request.URL.Host}
+	for name, values := range request.Header {
+		signed[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(signed))
+	for name := range signed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + signed[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		request.Method,
+		request.URL.EscapedPath(),
+		request.URL.Query().Encode(),
+		canonicalHeaders.String(),
+		signedHeaders,
+		emptyPayloadHash,
+	}, "\n")
+
+	scope := strings.Join([]string{date, c.Region, "s3", "aws4_request"}, "/")
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hex.EncodeToString(requestHash[:]),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+c.SecretKey), date)
+	key = hmacSHA256(key, c.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	request.Header.Set(headers.Authorization, fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		c.AccessKey, scope, signedHeaders, signature,
+	))
+}
+
+// hmacSHA256 returns the HMAC-SHA256 of data using key.
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	_, _ = mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,403 @@
+//
+// Copyright (c) 2024 Quadient Group AG
+//
+// This file is subject to the terms and conditions defined in the
+// 'LICENSE' file found in the root of this source code package.
+//
+
+package stage
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	cp "github.com/otiai10/copy"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// Staging actions.
+const (
+	ActionCopy    = "copy"
+	ActionExtract = "extract"
+)
+
+// FilesToCopy describes a file or directory to be staged.
+type FilesToCopy struct {
+	Source          string   `mapstructure:"src"`
+	Destination     string   `mapstructure:"dest"`
+	Action          string   `mapstructure:"action"`
+	SHA256          string   `mapstructure:"sha256"`
+	Verify          bool     `mapstructure:"verify"`
+	SkipUnchanged   bool     `mapstructure:"skipUnchanged"`
+	Compare         string   `mapstructure:"compare"`
+	Format          string   `mapstructure:"format"`
+	StripComponents int      `mapstructure:"stripComponents"`
+	Include         []string `mapstructure:"include"`
+	Exclude         []string `mapstructure:"exclude"`
+	Flatten         bool     `mapstructure:"flatten"`
+	FileMode        string   `mapstructure:"fileMode"`
+	DirMode         string   `mapstructure:"dirMode"`
+	UID             *int     `mapstructure:"uid"`
+	GID             *int     `mapstructure:"gid"`
+	Overwrite       string   `mapstructure:"overwrite"`
+	Symlinks        string   `mapstructure:"symlinks"`
+	Template        bool     `mapstructure:"template"`
+}
+
+// Options configures how entries are staged.
+type Options struct {
+	// CacheDir holds files downloaded from remote sources. Defaults
+	// to a directory below the system's temporary directory.
+	CacheDir string
+	// DownloadTimeout limits each download of a remote source,
+	// including reading its content. Defaults to DefaultDownloadTimeout.
+	DownloadTimeout time.Duration
+	// MaxEntrySize limits the size of each file extracted from an
+	// archive. Defaults to DefaultMaxEntrySize.
+	MaxEntrySize int64
+	// Transaction, if set, records the changes made so that they can
+	// be rolled back.
+	Transaction *Transaction
+	// Progress, if set, counts and reports the bytes staged.
+	Progress *Progress
+	// TemplateData is passed to the sources of template entries when
+	// they are rendered.
+	TemplateData map[string]interface{}
+}
+
+// Result lists the destination files written or left untouched when
+// staging an entry.
+type Result struct {
+	Copied  []string
+	Skipped []string
+	mutex   sync.Mutex
+}
+
+// newResult creates an empty Result.
+func newResult() *Result {
+	return &Result{}
+}
+
+// copied records a file written to dest.
+func (r *Result) copied(dest string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.Copied = append(r.Copied, dest)
+}
+
+// skipped records a destination file left untouched.
+func (r *Result) skipped(dest string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.Skipped = append(r.Skipped, dest)
+}
+
+// Stage copies (or extracts) the entry's source to its destination,
+// optionally skipping unchanged files and verifying the content.
+// Remote sources are downloaded to the cache directory first.
+func Stage(f FilesToCopy, opts Options) (*Result, error) {
+	if err := f.validate(); err != nil {
+		return nil, err
+	}
+
+	if isRemote(f.Source) {
+		if hasGlob(f.Source) {
+			return nil, errors.Errorf("source patterns cannot be used with remote source: %s", f.Source)
+		}
+		local, err := opts.download(f.Source, f.SHA256)
+		if err != nil {
+			return nil, err
+		}
+		f.Source = local
+	}
+
+	switch strings.ToLower(f.Action) {
+	case ActionCopy, "":
+		if f.Template {
+			return f.render(opts)
+		}
+		if hasGlob(f.Source) {
+			return f.stageGlob(opts)
+		}
+	case ActionExtract:
+		if hasGlob(f.Source) {
+			return nil, errors.Errorf("source patterns cannot be used with extract action: %s", f.Source)
+		}
+		result := newResult()
+		return result, f.extract(result, opts)
+	default:
+		return nil, errors.Errorf("unsupported staging action: %s", f.Action)
+	}
+
+	return f.copy(opts)
+}
+
+// copy copies the entry's source file or directory to its destination.
+func (f *FilesToCopy) copy(opts Options) (*Result, error) {
+	info, err := os.Stat(f.Source)
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading source file info")
+	}
+	if f.SHA256 != "" && info.IsDir() {
+		return nil, errors.Errorf("sha256 cannot be used with directory source: %s", f.Source)
+	}
+
+	result := newResult()
+	if info.IsDir() {
+		if err = f.copyDir(result, opts); err != nil {
+			return result, err
+		}
+	} else {
+		var skip bool
+		if skip, err = f.skip(info, f.Source, f.Destination); err != nil {
+			return nil, err
+		}
+		if skip {
+			result.skipped(f.Destination)
+			return result, nil
+		}
+		if err = f.mkdirAll(filepath.Dir(f.Destination), opts.Transaction); err != nil {
+			return nil, err
+		}
+		if err = f.copyFile(f.Source, f.Destination, info, opts); err != nil {
+			return result, err
+		}
+		result.copied(f.Destination)
+	}
+
+	return result, nil
+}
+
+// copyDir copies the entry's source directory into its destination,
+// merging it into an existing directory file by file.
+func (f *FilesToCopy) copyDir(result *Result, opts Options) error {
+	tx := opts.Transaction
+	if err := f.mkdirAll(f.Destination, tx); err != nil {
+		return err
+	}
+
+	dirs := []string{f.Destination}
+	copyOpts := f.copyOptions()
+	copyOpts.Skip = func(srcInfo os.FileInfo, src string, dest string) (bool, error) {
+		// Directories are always traversed.
+		if srcInfo.IsDir() {
+			dirs = append(dirs, dest)
+			return false, f.mkdirAll(dest, tx)
+		}
+		if rel, relErr := filepath.Rel(f.Source, src); relErr == nil && !f.matches(filepath.ToSlash(rel)) {
+			return true, nil
+		}
+		// Links followed with deep handling are passed here again as
+		// their target.
+		link := srcInfo.Mode()&os.ModeSymlink != 0
+		if link {
+			switch strings.ToLower(f.Symlinks) {
+			case SymlinksSkip:
+				return true, nil
+			case SymlinksDeep:
+				return false, nil
+			}
+		}
+		skip, err := f.skipFile(srcInfo, src, dest, link)
+		if err != nil || skip {
+			if skip {
+				result.skipped(dest)
+			}
+			return true, err
+		}
+
+		// Files and links are staged here rather than by cp.Copy.
+		if link {
+			err = copyLink(src, dest, tx)
+		} else {
+			err = f.copyFile(src, dest, srcInfo, opts)
+		}
+		if err != nil {
+			return true, err
+		}
+		result.copied(dest)
+		return true, nil
+	}
+	if err := cp.Copy(f.Source, f.Destination, copyOpts); err != nil {
+		return errors.Wrap(err, "error copying file")
+	}
+
+	if f.hasAttributes() {
+		for _, dir := range dirs {
+			if err := f.applyAttributes(dir, true); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// copyFile copies the file at src, described by info, to dest through
+// a temporary file that is moved into place once written and verified,
+// so that a file failing verification never replaces dest.
+func (f *FilesToCopy) copyFile(src string, dest string, info os.FileInfo, opts Options) error {
+	source, err := os.Open(src)
+	if err != nil {
+		return errors.Wrap(err, "error opening source file")
+	}
+	defer func() { _ = source.Close() }()
+
+	file, err := createTemp(dest)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(file.Name()) }()
+
+	_, err = io.Copy(file, opts.Progress.reader(src, info.Size(), source))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return errors.Wrap(err, "error copying file")
+	}
+	if err = os.Chmod(file.Name(), info.Mode().Perm()); err != nil {
+		return errors.Wrap(err, "error setting file mode")
+	}
+	// Unchanged files are detected by their modification time.
+	if f.SkipUnchanged {
+		if err = os.Chtimes(file.Name(), info.ModTime(), info.ModTime()); err != nil {
+			return errors.Wrap(err, "error setting file times")
+		}
+	}
+	if err = f.applyAttributes(file.Name(), false); err != nil {
+		return err
+	}
+	if err = f.verify(src, file.Name()); err != nil {
+		return err
+	}
+	return opts.Transaction.install(file.Name(), dest)
+}
+
+// copyLink creates a symbolic link at dest with the target of the one
+// at src.
+func copyLink(src string, dest string, tx *Transaction) error {
+	target, err := os.Readlink(src)
+	if err != nil {
+		return errors.Wrap(err, "error reading symbolic link")
+	}
+	if err = tx.replace(dest, false); err != nil {
+		return err
+	}
+	return errors.Wrap(os.Symlink(target, dest), "error creating symbolic link")
+}
+
+// stageGlob copies the files matching the entry's source pattern into
+// its destination directory.
+func (f *FilesToCopy) stageGlob(opts Options) (*Result, error) {
+	if f.SHA256 != "" {
+		return nil, errors.Errorf("sha256 cannot be used with source pattern: %s", f.Source)
+	}
+
+	files, err := f.expandGlob()
+	if err != nil {
+		return nil, err
+	}
+	log.WithFields(log.Fields{
+		"src":   f.Source,
+		"files": len(files),
+	}).Debug("expanded source pattern")
+
+	result := newResult()
+	for src, dest := range files {
+		var info os.FileInfo
+		if info, err = os.Lstat(src); err != nil {
+			return result, errors.Wrap(err, "error reading source file info")
+		}
+		link := info.Mode()&os.ModeSymlink != 0 && strings.ToLower(f.Symlinks) != SymlinksDeep
+		if !link {
+			if info, err = os.Stat(src); err != nil {
+				return result, errors.Wrap(err, "error reading source file info")
+			}
+		}
+		var skip bool
+		if skip, err = f.skipFile(info, src, dest, link); err != nil {
+			return result, err
+		}
+		if skip {
+			result.skipped(dest)
+			continue
+		}
+		if err = f.mkdirAll(filepath.Dir(dest), opts.Transaction); err != nil {
+			return result, err
+		}
+		if link {
+			err = copyLink(src, dest, opts.Transaction)
+		} else {
+			err = f.copyFile(src, dest, info, opts)
+		}
+		if err != nil {
+			return result, err
+		}
+		result.copied(dest)
+	}
+
+	return result, nil
+}
+
+// skip reports whether copying src to dest can be skipped due to the
+// overwrite policy or because the destination is unchanged.
+func (f *FilesToCopy) skip(srcInfo os.FileInfo, src string, dest string) (bool, error) {
+	if skip, err := f.overwriteSkip(srcInfo, dest); err != nil || skip {
+		return skip, err
+	}
+	if !f.SkipUnchanged {
+		return false, nil
+	}
+
+	same, err := unchanged(f.Compare, srcInfo, src, dest, f.SHA256)
+	if err != nil {
+		return false, err
+	}
+	if same {
+		log.WithFields(log.Fields{
+			"src":  src,
+			"dest": dest,
+		}).Debug("skipping unchanged file")
+	}
+	return same, nil
+}
+
+// skipFile is skip for a file or, if link is set, a symbolic link,
+// which is only subject to the overwrite policy.
+func (f *FilesToCopy) skipFile(srcInfo os.FileInfo, src string, dest string, link bool) (bool, error) {
+	if link {
+		return f.overwriteSkip(srcInfo, dest)
+	}
+	return f.skip(srcInfo, src, dest)
+}
+
+// verify checks a file copied from src against the expected checksum
+// or, if verification was requested, against its source file.
+func (f *FilesToCopy) verify(src string, path string) error {
+	expected := f.SHA256
+	if expected == "" && f.Verify {
+		sum, err := FileSHA256(src)
+		if err != nil {
+			return err
+		}
+		expected = sum
+	}
+	if expected == "" {
+		return nil
+	}
+	// The file is reported by its source, as path is a temporary file.
+	actual, err := FileSHA256(path)
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(actual, expected) {
+		return errors.Errorf("checksum mismatch for %s: expected %s, got %s", src, expected, actual)
+	}
+	log.WithField("src", src).Debug("verified file checksum")
+	return nil
+}
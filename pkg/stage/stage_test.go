@@ -0,0 +1,157 @@
+//
+// Copyright (c) 2024 Quadient Group AG
+//
+// This file is subject to the terms and conditions defined in the
+// 'LICENSE' file found in the root of this source code package.
+//
+
+package stage_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/robertwtucker/spt-util/pkg/stage"
+	"github.com/stretchr/testify/assert"
+)
+
+// fooSHA256 is the SHA-256 digest of "foo".
+const fooSHA256 = "2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae"
+
+func writeFile(t *testing.T, path string, content string) {
+	t.Helper()
+	assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+}
+
+func TestFileSHA256(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "foo.txt")
+	writeFile(t, path, "foo")
+
+	sum, err := stage.FileSHA256(path)
+	assert.NoError(t, err)
+	assert.Equal(t, fooSHA256, sum)
+}
+
+func TestStage_CopyFile(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src", "foo.txt")
+	dest := filepath.Join(dir, "dest", "foo.txt")
+	writeFile(t, src, "foo")
+
+	result, err := stage.Stage(stage.FilesToCopy{Source: src, Destination: dest, SHA256: fooSHA256}, stage.Options{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{dest}, result.Copied)
+
+	content, err := os.ReadFile(dest)
+	assert.NoError(t, err)
+	assert.Equal(t, "foo", string(content))
+}
+
+func TestStage_ChecksumMismatch(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "foo.txt")
+	writeFile(t, src, "bar")
+
+	_, err := stage.Stage(stage.FilesToCopy{
+		Source:      src,
+		Destination: filepath.Join(dir, "dest.txt"),
+		SHA256:      fooSHA256,
+	}, stage.Options{})
+	assert.ErrorContains(t, err, "checksum mismatch")
+	assert.NoFileExists(t, filepath.Join(dir, "dest.txt"))
+}
+
+func TestStage_ChecksumMismatchKeepsDestination(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	dest := filepath.Join(dir, "dest")
+	writeFile(t, filepath.Join(src, "foo.txt"), "foo")
+	writeFile(t, filepath.Join(dest, "foo.txt"), "old foo")
+
+	// Without a transaction, as with --no-rollback, nothing restores a
+	// file that failed verification.
+	_, err := stage.Stage(stage.FilesToCopy{
+		Source:      filepath.Join(src, "*.txt"),
+		Destination: dest,
+		Verify:      true,
+	}, stage.Options{})
+	assert.NoError(t, err)
+
+	writeFile(t, filepath.Join(src, "foo.txt"), "bar")
+	_, err = stage.Stage(stage.FilesToCopy{
+		Source:      filepath.Join(src, "foo.txt"),
+		Destination: filepath.Join(dest, "foo.txt"),
+		SHA256:      fooSHA256,
+	}, stage.Options{})
+	assert.ErrorContains(t, err, "checksum mismatch")
+
+	content, err := os.ReadFile(filepath.Join(dest, "foo.txt"))
+	assert.NoError(t, err)
+	assert.Equal(t, "foo", string(content))
+	entries, err := os.ReadDir(dest)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestStage_ChecksumWithDirectory(t *testing.T) {
+	dir := t.TempDir()
+
+	_, err := stage.Stage(stage.FilesToCopy{
+		Source:      dir,
+		Destination: filepath.Join(dir, "dest"),
+		SHA256:      fooSHA256,
+	}, stage.Options{})
+	assert.Error(t, err)
+}
+
+func TestStage_SkipUnchanged(t *testing.T) {
+	for _, mode := range []string{stage.CompareSize, stage.CompareModTime, stage.CompareHash} {
+		t.Run(mode, func(t *testing.T) {
+			dir := t.TempDir()
+			src := filepath.Join(dir, "src")
+			dest := filepath.Join(dir, "dest")
+			writeFile(t, filepath.Join(src, "foo.txt"), "foo")
+			writeFile(t, filepath.Join(src, "sub", "bar.txt"), "bar")
+			entry := stage.FilesToCopy{Source: src, Destination: dest, SkipUnchanged: true, Compare: mode}
+
+			result, err := stage.Stage(entry, stage.Options{})
+			assert.NoError(t, err)
+			assert.Len(t, result.Copied, 2)
+			assert.Empty(t, result.Skipped)
+
+			result, err = stage.Stage(entry, stage.Options{})
+			assert.NoError(t, err)
+			assert.Empty(t, result.Copied)
+			assert.Len(t, result.Skipped, 2)
+		})
+	}
+}
+
+func TestStage_SkipUnchangedDetectsChange(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "foo.txt")
+	dest := filepath.Join(dir, "dest.txt")
+	writeFile(t, src, "foo")
+	writeFile(t, dest, "bar")
+	entry := stage.FilesToCopy{Source: src, Destination: dest, SkipUnchanged: true, Compare: stage.CompareHash}
+
+	result, err := stage.Stage(entry, stage.Options{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{dest}, result.Copied)
+}
+
+func TestStage_Verify(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	writeFile(t, filepath.Join(src, "foo.txt"), "foo")
+
+	result, err := stage.Stage(stage.FilesToCopy{
+		Source:      src,
+		Destination: filepath.Join(dir, "dest"),
+		Verify:      true,
+	}, stage.Options{})
+	assert.NoError(t, err)
+	assert.Len(t, result.Copied, 1)
+}
@@ -0,0 +1,165 @@
+//
+// Copyright (c) 2024 Quadient Group AG
+//
+// This file is subject to the terms and conditions defined in the
+// 'LICENSE' file found in the root of this source code package.
+//
+
+package stage
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// render stages the entry's source file(s) through text/template using
+// the template data. A directory or pattern source renders each of its
+// (matching) files.
+func (f *FilesToCopy) render(opts Options) (*Result, error) {
+	var files map[string]string // src -> dest
+	if hasGlob(f.Source) {
+		var err error
+		if files, err = f.expandGlob(); err != nil {
+			return nil, err
+		}
+	} else {
+		info, err := os.Stat(f.Source)
+		if err != nil {
+			return nil, errors.Wrap(err, "error reading source file info")
+		}
+		if info.IsDir() {
+			if files, err = f.expand(f.Source, "**"); err != nil {
+				return nil, err
+			}
+		} else {
+			files = map[string]string{f.Source: f.Destination}
+		}
+	}
+
+	sources := make([]string, 0, len(files))
+	for src := range files {
+		sources = append(sources, src)
+	}
+	sort.Strings(sources)
+
+	result := newResult()
+	for _, src := range sources {
+		if err := f.renderFile(src, files[src], result, opts); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// renderFile renders the template at src to dest. With skipUnchanged,
+// a destination whose content equals the rendered output is left
+// untouched.
+func (f *FilesToCopy) renderFile(src string, dest string, result *Result, opts Options) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return errors.Wrap(err, "error reading source file info")
+	}
+	var skip bool
+	if skip, err = f.overwriteSkip(info, dest); err != nil {
+		return err
+	}
+	if skip {
+		result.skipped(dest)
+		return nil
+	}
+
+	file, err := os.Open(src)
+	if err != nil {
+		return errors.Wrap(err, "error opening template")
+	}
+	text, err := io.ReadAll(opts.Progress.reader(src, info.Size(), file))
+	_ = file.Close()
+	if err != nil {
+		return errors.Wrap(err, "error reading template")
+	}
+
+	tmpl, err := template.New(filepath.Base(src)).
+		Option("missingkey=error").
+		Funcs(template.FuncMap{"config": opts.configValue}).
+		Parse(string(text))
+	if err != nil {
+		return errors.Wrapf(err, "error parsing template %s", src)
+	}
+	var rendered bytes.Buffer
+	if err = tmpl.Execute(&rendered, opts.TemplateData); err != nil {
+		return errors.Wrapf(err, "error rendering template %s", src)
+	}
+
+	if f.SkipUnchanged {
+		if existing, readErr := os.ReadFile(dest); readErr == nil && bytes.Equal(existing, rendered.Bytes()) {
+			log.WithFields(log.Fields{
+				"src":  src,
+				"dest": dest,
+			}).Debug("skipping unchanged file")
+			result.skipped(dest)
+			return nil
+		}
+	}
+
+	log.WithFields(log.Fields{
+		"src":  src,
+		"dest": dest,
+	}).Debug("rendering template")
+	if err = f.mkdirAll(filepath.Dir(dest), opts.Transaction); err != nil {
+		return err
+	}
+	staged, err := createTemp(dest)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(staged.Name()) }()
+	_, err = staged.Write(rendered.Bytes())
+	if closeErr := staged.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return errors.Wrap(err, "error writing rendered template")
+	}
+	if err = os.Chmod(staged.Name(), info.Mode().Perm()); err != nil {
+		return errors.Wrap(err, "error setting file mode")
+	}
+	if err = f.applyAttributes(staged.Name(), false); err != nil {
+		return err
+	}
+	if err = opts.Transaction.install(staged.Name(), dest); err != nil {
+		return err
+	}
+	result.copied(dest)
+	return nil
+}
+
+// configValue returns the template data value at a dotted key path,
+// matching keys case-insensitively like the configuration does.
+func (o *Options) configValue(key string) (interface{}, error) {
+	var value interface{} = o.TemplateData
+	for _, name := range strings.Split(key, ".") {
+		values, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, errors.Errorf("config value not found: %s", key)
+		}
+		found := false
+		for k, v := range values {
+			if strings.EqualFold(k, name) {
+				value, found = v, true
+				break
+			}
+		}
+		if !found {
+			return nil, errors.Errorf("config value not found: %s", key)
+		}
+	}
+	return value, nil
+}
@@ -0,0 +1,100 @@
+//
+// Copyright (c) 2024 Quadient Group AG
+//
+// This file is subject to the terms and conditions defined in the
+// 'LICENSE' file found in the root of this source code package.
+//
+
+package stage_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/robertwtucker/spt-util/pkg/stage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var templateData = map[string]interface{}{
+	"global": map[string]interface{}{
+		"release":   "inspire",
+		"namespace": "demo",
+	},
+	"demo": map[string]interface{}{
+		"server": "https://scaler.example.com",
+		"init": map[string]interface{}{
+			"envfile": "/deployment/env.json",
+		},
+	},
+}
+
+func TestStage_Template(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "job.yaml")
+	dest := filepath.Join(dir, "dest", "job.yaml")
+	writeFile(t, src, `release: {{ .global.release }}-{{ .global.namespace }}
+server: {{ .demo.server }}
+env: {{ config "demo.init.envFile" }}
+`)
+
+	entry := stage.FilesToCopy{Source: src, Destination: dest, Template: true, SkipUnchanged: true}
+	opts := stage.Options{TemplateData: templateData}
+	result, err := stage.Stage(entry, opts)
+	require.NoError(t, err)
+	assert.Equal(t, []string{dest}, result.Copied)
+
+	content, err := os.ReadFile(dest)
+	require.NoError(t, err)
+	assert.Equal(t, `release: inspire-demo
+server: https://scaler.example.com
+env: /deployment/env.json
+`, string(content))
+
+	// The rendered output is compared, not the template source.
+	later := time.Now().Add(time.Hour)
+	require.NoError(t, os.Chtimes(src, later, later))
+	result, err = stage.Stage(entry, opts)
+	require.NoError(t, err)
+	assert.Empty(t, result.Copied)
+	assert.Equal(t, []string{dest}, result.Skipped)
+}
+
+func TestStage_TemplateDirectory(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	writeFile(t, filepath.Join(src, "a.txt"), "{{ .global.release }}")
+	writeFile(t, filepath.Join(src, "sub", "b.txt"), "{{ .global.namespace }}")
+
+	_, err := stage.Stage(stage.FilesToCopy{
+		Source:      src,
+		Destination: filepath.Join(dir, "dest"),
+		Template:    true,
+	}, stage.Options{TemplateData: templateData})
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(filepath.Join(dir, "dest", "sub", "b.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "demo", string(content))
+}
+
+func TestStage_TemplateErrors(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "job.yaml")
+	dest := filepath.Join(dir, "dest", "job.yaml")
+	opts := stage.Options{TemplateData: templateData}
+
+	writeFile(t, src, "{{ .global.missing }}")
+	_, err := stage.Stage(stage.FilesToCopy{Source: src, Destination: dest, Template: true}, opts)
+	assert.Error(t, err)
+	assert.NoFileExists(t, dest)
+
+	writeFile(t, src, `{{ config "demo.missing" }}`)
+	_, err = stage.Stage(stage.FilesToCopy{Source: src, Destination: dest, Template: true}, opts)
+	assert.Error(t, err)
+
+	_, err = stage.Stage(stage.FilesToCopy{Source: src, Destination: dest, Template: true, Verify: true}, opts)
+	assert.Error(t, err)
+}
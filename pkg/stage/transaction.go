@@ -0,0 +1,230 @@
+//
+// Copyright (c) 2024 Quadient Group AG
+//
+// This file is subject to the terms and conditions defined in the
+// 'LICENSE' file found in the root of this source code package.
+//
+
+package stage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// journalEntry records a single change made to the file system.
+type journalEntry struct {
+	path   string
+	backup string // backup of a replaced file, if any
+	dir    bool   // path is a directory created while staging
+}
+
+// Transaction records the changes made while staging so that they can
+// be restored if staging fails: replaced files are kept as a backup
+// alongside the original and new files and directories are removed on
+// rollback. Entries may share a Transaction while they are staged
+// concurrently. A nil Transaction records nothing.
+//
+// Files are written to temporary files of their own next to their
+// destination and renamed into place once written (see install), so
+// that readers never see a partially written file. Existing directories
+// are merged into, backing up only the files replaced.
+type Transaction struct {
+	id      string
+	journal []journalEntry
+	seen    map[string]bool
+	mutex   sync.Mutex
+}
+
+// NewTransaction creates an empty Transaction.
+func NewTransaction() *Transaction {
+	return &Transaction{
+		id:   fmt.Sprintf("%d", time.Now().UnixNano()),
+		seen: map[string]bool{},
+	}
+}
+
+// sibling returns the path of a staging file of the Transaction next to
+// dest.
+func (t *Transaction) sibling(dest string, suffix string) string {
+	return filepath.Join(filepath.Dir(dest), fmt.Sprintf(".%s.%s.%s", filepath.Base(dest), t.id, suffix))
+}
+
+// createTemp creates a temporary file next to dest, which is written
+// and then moved into place by install. Every call creates a new file,
+// so that entries staging the same destination don't interfere.
+func createTemp(dest string) (*os.File, error) {
+	file, err := os.CreateTemp(filepath.Dir(dest), "."+filepath.Base(dest)+"-*.tmp")
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating file")
+	}
+	return file, nil
+}
+
+// install moves the file written to temp into place at dest, recording
+// the replacement of dest (see replace). temp is removed on failure.
+func (t *Transaction) install(temp string, dest string) error {
+	err := t.replace(dest, true)
+	if err == nil {
+		err = errors.Wrap(os.Rename(temp, dest), "error moving staged file into place")
+	}
+	if err != nil {
+		_ = os.Remove(temp)
+	}
+	return err
+}
+
+// replace records that dest is about to be replaced. An existing file
+// (or link) is kept as a backup, which is a hard link to it if keep is
+// set and dest is to be renamed over, or dest itself moved aside
+// otherwise. A missing one is recorded as new. Files replaced before by
+// the Transaction keep their first backup.
+func (t *Transaction) replace(dest string, keep bool) error {
+	if t == nil {
+		return removeUnlessKept(dest, keep)
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.seen[dest] {
+		return removeUnlessKept(dest, keep)
+	}
+	info, err := os.Lstat(dest)
+	switch {
+	case os.IsNotExist(err):
+		t.journal = append(t.journal, journalEntry{path: dest})
+	case err != nil:
+		return errors.Wrap(err, "error reading destination file info")
+	case info.IsDir():
+		return errors.Errorf("destination is a directory: %s", dest)
+	default:
+		backup := t.sibling(dest, "bak")
+		if !keep {
+			err = os.Rename(dest, backup)
+		} else if err = os.Link(dest, backup); err != nil {
+			// Hard links keep the file in place, but are not supported
+			// everywhere.
+			err = os.Rename(dest, backup)
+		}
+		if err != nil {
+			return errors.Wrap(err, "error backing up destination")
+		}
+		t.journal = append(t.journal, journalEntry{path: dest, backup: backup})
+	}
+	t.seen[dest] = true
+	return nil
+}
+
+// removeUnlessKept removes dest, if it exists, unless keep is set.
+func removeUnlessKept(dest string, keep bool) error {
+	if keep {
+		return nil
+	}
+	if err := os.Remove(dest); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "error replacing destination")
+	}
+	return nil
+}
+
+// createdDir records a directory created while staging.
+func (t *Transaction) createdDir(dir string) {
+	if t == nil {
+		return
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if !t.seen[dir] {
+		t.seen[dir] = true
+		t.journal = append(t.journal, journalEntry{path: dir, dir: true})
+	}
+}
+
+// Rollback undoes the recorded changes: new files are removed and
+// backups restored in reverse order, then the (empty) new directories
+// are removed, deepest first. Entries staged concurrently may have
+// created a directory after another one wrote into it.
+func (t *Transaction) Rollback() error {
+	if t == nil {
+		return nil
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	var failed int
+	var dirs []string
+	for i := len(t.journal) - 1; i >= 0; i-- {
+		entry := t.journal[i]
+		if entry.dir {
+			dirs = append(dirs, entry.path)
+			continue
+		}
+		var err error
+		if entry.backup != "" {
+			if err = os.Remove(entry.path); err == nil || os.IsNotExist(err) {
+				err = os.Rename(entry.backup, entry.path)
+			}
+		} else {
+			err = os.Remove(entry.path)
+		}
+		if !t.rolledBack(entry.path, err) {
+			failed++
+		}
+	}
+	sort.Slice(dirs, func(i, j int) bool { return len(dirs[i]) > len(dirs[j]) })
+	for _, dir := range dirs {
+		if !t.rolledBack(dir, os.Remove(dir)) {
+			failed++
+		}
+	}
+	t.journal = nil
+
+	if failed > 0 {
+		return errors.Errorf("unable to roll back %d staged file(s)", failed)
+	}
+	return nil
+}
+
+// rolledBack logs the outcome of rolling back the change to path and
+// reports whether it succeeded.
+func (t *Transaction) rolledBack(path string, err error) bool {
+	if err != nil && !os.IsNotExist(err) {
+		log.WithField("path", path).Error("unable to roll back staged file: ", err)
+		return false
+	}
+	log.WithField("path", path).Debug("rolled back staged file")
+	return true
+}
+
+// Commit accepts the recorded changes and removes the backups.
+func (t *Transaction) Commit() error {
+	if t == nil {
+		return nil
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	var failed int
+	for _, entry := range t.journal {
+		if entry.backup == "" {
+			continue
+		}
+		if err := os.Remove(entry.backup); err != nil && !os.IsNotExist(err) {
+			log.WithField("path", entry.backup).Warn("unable to remove backup file: ", err)
+			failed++
+		}
+	}
+	t.journal = nil
+
+	if failed > 0 {
+		return errors.Errorf("unable to remove %d backup file(s)", failed)
+	}
+	return nil
+}
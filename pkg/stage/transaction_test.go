@@ -0,0 +1,185 @@
+//
+// Copyright (c) 2024 Quadient Group AG
+//
+// This file is subject to the terms and conditions defined in the
+// 'LICENSE' file found in the root of this source code package.
+//
+
+package stage_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/robertwtucker/spt-util/pkg/stage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransaction_Rollback(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	dest := filepath.Join(dir, "dest")
+	writeFile(t, filepath.Join(src, "foo.txt"), "new foo")
+	writeFile(t, filepath.Join(src, "sub", "bar.txt"), "new bar")
+	writeFile(t, filepath.Join(dest, "foo.txt"), "old foo")
+
+	tx := stage.NewTransaction()
+	opts := stage.Options{Transaction: tx}
+	_, err := stage.Stage(stage.FilesToCopy{Source: src, Destination: dest}, opts)
+	assert.NoError(t, err)
+	_, err = stage.Stage(stage.FilesToCopy{
+		Source:      filepath.Join(src, "foo.txt"),
+		Destination: filepath.Join(dir, "other", "foo.txt"),
+	}, opts)
+	assert.NoError(t, err)
+
+	assert.NoError(t, tx.Rollback())
+
+	content, err := os.ReadFile(filepath.Join(dest, "foo.txt"))
+	assert.NoError(t, err)
+	assert.Equal(t, "old foo", string(content))
+	assert.NoDirExists(t, filepath.Join(dest, "sub"))
+	assert.NoDirExists(t, filepath.Join(dir, "other"))
+
+	entries, err := os.ReadDir(dest)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestTransaction_RollbackExtract(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "bundle.zip")
+	dest := filepath.Join(dir, "dest")
+	writeZip(t, src, archiveFiles)
+	writeFile(t, filepath.Join(dest, "bundle", "foo.txt"), "old foo")
+
+	tx := stage.NewTransaction()
+	_, err := stage.Stage(stage.FilesToCopy{
+		Source:      src,
+		Destination: dest,
+		Action:      stage.ActionExtract,
+	}, stage.Options{Transaction: tx})
+	assert.NoError(t, err)
+
+	assert.NoError(t, tx.Rollback())
+
+	content, err := os.ReadFile(filepath.Join(dest, "bundle", "foo.txt"))
+	assert.NoError(t, err)
+	assert.Equal(t, "old foo", string(content))
+	assert.NoDirExists(t, filepath.Join(dest, "bundle", "sub"))
+}
+
+func TestTransaction_Commit(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "foo.txt")
+	dest := filepath.Join(dir, "dest")
+	writeFile(t, src, "new foo")
+	writeFile(t, filepath.Join(dest, "foo.txt"), "old foo")
+
+	tx := stage.NewTransaction()
+	_, err := stage.Stage(stage.FilesToCopy{
+		Source:      src,
+		Destination: filepath.Join(dest, "foo.txt"),
+	}, stage.Options{Transaction: tx})
+	assert.NoError(t, err)
+
+	assert.NoError(t, tx.Commit())
+
+	content, err := os.ReadFile(filepath.Join(dest, "foo.txt"))
+	assert.NoError(t, err)
+	assert.Equal(t, "new foo", string(content))
+
+	entries, err := os.ReadDir(dest)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestTransaction_RollbackFailedEntry(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "bundle.zip")
+	dest := filepath.Join(dir, "dest")
+	writeZip(t, src, map[string]string{"a/foo.txt": "new foo", "b/foo.txt": "other foo"})
+	writeFile(t, filepath.Join(dest, "foo.txt"), "old foo")
+
+	tx := stage.NewTransaction()
+	_, err := stage.Stage(stage.FilesToCopy{
+		Source:      src,
+		Destination: dest,
+		Action:      stage.ActionExtract,
+		Flatten:     true,
+	}, stage.Options{Transaction: tx})
+	assert.Error(t, err)
+
+	assert.NoError(t, tx.Rollback())
+	content, err := os.ReadFile(filepath.Join(dest, "foo.txt"))
+	assert.NoError(t, err)
+	assert.Equal(t, "old foo", string(content))
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2) // bundle.zip, dest
+}
+
+func TestTransaction_BacksUpReplacedFilesOnly(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	dest := filepath.Join(dir, "dest")
+	writeFile(t, filepath.Join(src, "foo.txt"), "new foo")
+	writeFile(t, filepath.Join(dest, "foo.txt"), "old foo")
+	writeFile(t, filepath.Join(dest, "other.txt"), "other")
+
+	tx := stage.NewTransaction()
+	_, err := stage.Stage(stage.FilesToCopy{Source: src, Destination: dest}, stage.Options{Transaction: tx})
+	assert.NoError(t, err)
+
+	// Only the replaced file has a backup; other files stay untouched.
+	entries, err := os.ReadDir(dest)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 3) // foo.txt, its backup, other.txt
+
+	assert.NoError(t, tx.Rollback())
+	content, err := os.ReadFile(filepath.Join(dest, "foo.txt"))
+	assert.NoError(t, err)
+	assert.Equal(t, "old foo", string(content))
+	entries, err = os.ReadDir(dest)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+}
+
+func TestTransaction_ConcurrentEntries(t *testing.T) {
+	dir := t.TempDir()
+	dest := filepath.Join(dir, "dest")
+	writeFile(t, filepath.Join(dest, "file-0.txt"), "old")
+
+	const files = 200
+	tx := stage.NewTransaction()
+	var wg sync.WaitGroup
+	errs := make(chan error, files)
+	for i := 0; i < files; i++ {
+		name := fmt.Sprintf("file-%d.txt", i)
+		src := filepath.Join(dir, "src", name)
+		writeFile(t, src, name)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := stage.Stage(stage.FilesToCopy{Source: src, Destination: filepath.Join(dest, name)},
+				stage.Options{Transaction: tx})
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+
+	assert.NoError(t, tx.Commit())
+	entries, err := os.ReadDir(dest)
+	assert.NoError(t, err)
+	assert.Len(t, entries, files)
+	content, err := os.ReadFile(filepath.Join(dest, "file-0.txt"))
+	assert.NoError(t, err)
+	assert.Equal(t, "file-0.txt", string(content))
+}
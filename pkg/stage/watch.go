@@ -0,0 +1,214 @@
+//
+// Copyright (c) 2024 Quadient Group AG
+//
+// This file is subject to the terms and conditions defined in the
+// 'LICENSE' file found in the root of this source code package.
+//
+
+package stage
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// watchRoot is the local path whose changes affect an entry.
+type watchRoot struct {
+	path      string
+	recursive bool   // path is a directory watched including subdirectories
+	pattern   string // files below path must match, if set
+}
+
+// Watcher watches the local sources of staging entries and reports the
+// entries whose sources changed. Remote sources are not watched.
+type Watcher struct {
+	entries  []FilesToCopy
+	roots    []*watchRoot // per entry; nil for remote sources
+	debounce time.Duration
+	watcher  *fsnotify.Watcher
+}
+
+// NewWatcher creates a Watcher for the entries' sources. Changes are
+// reported once no further changes occurred for the debounce interval.
+func NewWatcher(entries []FilesToCopy, debounce time.Duration) (*Watcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating file watcher")
+	}
+	w := &Watcher{
+		entries:  entries,
+		roots:    make([]*watchRoot, len(entries)),
+		debounce: debounce,
+		watcher:  watcher,
+	}
+
+	for i, f := range entries {
+		if err = w.add(i, f); err != nil {
+			_ = watcher.Close()
+			return nil, err
+		}
+	}
+	return w, nil
+}
+
+// add starts watching the source of entry i. Files are watched through
+// their parent directory so that editors replacing a file are noticed.
+func (w *Watcher) add(i int, f FilesToCopy) error {
+	if isRemote(f.Source) {
+		log.WithField("src", f.Source).Warn("remote sources are not watched")
+		return nil
+	}
+
+	source, pattern := f.Source, ""
+	if hasGlob(source) {
+		source, pattern = splitGlob(source)
+	}
+	path, err := filepath.Abs(source)
+	if err != nil {
+		return errors.Wrap(err, "error resolving source path")
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return errors.Wrap(err, "error reading source file info")
+	}
+
+	root := &watchRoot{path: path, recursive: info.IsDir(), pattern: pattern}
+	w.roots[i] = root
+	if !root.recursive {
+		return w.watch(filepath.Dir(path))
+	}
+	return w.watchTree(path)
+}
+
+// watch adds a single directory to the watch list.
+func (w *Watcher) watch(dir string) error {
+	if err := w.watcher.Add(dir); err != nil {
+		return errors.Wrapf(err, "error watching %s", dir)
+	}
+	log.WithField("dir", dir).Debug("watching directory")
+	return nil
+}
+
+// watchTree adds dir and all of its subdirectories to the watch list.
+func (w *Watcher) watchTree(dir string) error {
+	return filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() {
+			return nil
+		}
+		return w.watch(path)
+	})
+}
+
+// Watch calls fn with the entries whose sources changed until ctx is
+// done. Calls to fn are not concurrent; changes made while fn runs are
+// reported in the next call.
+func (w *Watcher) Watch(ctx context.Context, fn func(changed []FilesToCopy)) error {
+	pending := map[int]bool{}
+	timer := time.NewTimer(w.debounce)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return nil
+			}
+			if event.Op == fsnotify.Chmod {
+				continue
+			}
+			w.watchCreatedDir(event)
+			changed := w.affected(event.Name)
+			if len(changed) == 0 {
+				continue
+			}
+			log.WithFields(log.Fields{
+				"path": event.Name,
+				"op":   event.Op.String(),
+			}).Debug("source changed")
+			for _, i := range changed {
+				pending[i] = true
+			}
+			timer.Reset(w.debounce)
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return nil
+			}
+			log.Error("error watching sources: ", err)
+		case <-timer.C:
+			changed := make([]FilesToCopy, 0, len(pending))
+			for i := range w.entries {
+				if pending[i] {
+					changed = append(changed, w.entries[i])
+				}
+			}
+			pending = map[int]bool{}
+			fn(changed)
+		}
+	}
+}
+
+// watchCreatedDir adds a directory created below a watched tree.
+func (w *Watcher) watchCreatedDir(event fsnotify.Event) {
+	if !event.Has(fsnotify.Create) {
+		return
+	}
+	if info, err := os.Stat(event.Name); err != nil || !info.IsDir() {
+		return
+	}
+	for _, root := range w.roots {
+		if root != nil && root.recursive && within(root.path, event.Name) {
+			if err := w.watchTree(event.Name); err != nil {
+				log.Warn("unable to watch new directory: ", err)
+			}
+			return
+		}
+	}
+}
+
+// affected returns the indexes of the entries affected by a change to
+// path.
+func (w *Watcher) affected(path string) []int {
+	var indexes []int
+	for i, root := range w.roots {
+		if root == nil {
+			continue
+		}
+		if path == root.path || (root.recursive && within(root.path, path) && root.matches(path)) {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
+
+// matches reports whether a path below the root matches its pattern.
+func (r *watchRoot) matches(path string) bool {
+	if r.pattern == "" {
+		return true
+	}
+	rel, err := filepath.Rel(r.path, path)
+	return err == nil && match(r.pattern, filepath.ToSlash(rel))
+}
+
+// within reports whether path is below dir.
+func within(dir string, path string) bool {
+	return strings.HasPrefix(path, dir+string(filepath.Separator))
+}
+
+// Close stops watching.
+func (w *Watcher) Close() error {
+	return w.watcher.Close()
+}
@@ -0,0 +1,75 @@
+//
+// Copyright (c) 2024 Quadient Group AG
+//
+// This file is subject to the terms and conditions defined in the
+// 'LICENSE' file found in the root of this source code package.
+//
+
+package stage_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/robertwtucker/spt-util/pkg/stage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatcher(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "single", "foo.txt")
+	tree := filepath.Join(dir, "tree")
+	writeFile(t, file, "foo")
+	writeFile(t, filepath.Join(dir, "single", "other.txt"), "other")
+	writeFile(t, filepath.Join(tree, "bar.txt"), "bar")
+
+	entries := []stage.FilesToCopy{
+		{Source: file, Destination: filepath.Join(dir, "dest", "foo.txt")},
+		{Source: tree, Destination: filepath.Join(dir, "dest", "tree")},
+		{Source: filepath.Join(dir, "single", "*.md"), Destination: filepath.Join(dir, "dest", "docs")},
+		{Source: "https://example.com/bundle.zip", Destination: filepath.Join(dir, "dest")},
+	}
+	watcher, err := stage.NewWatcher(entries, 50*time.Millisecond)
+	require.NoError(t, err)
+	defer func() { _ = watcher.Close() }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes := make(chan []stage.FilesToCopy)
+	done := make(chan error)
+	go func() {
+		done <- watcher.Watch(ctx, func(changed []stage.FilesToCopy) { changes <- changed })
+	}()
+
+	next := func() []stage.FilesToCopy {
+		select {
+		case changed := <-changes:
+			return changed
+		case <-time.After(5 * time.Second):
+			t.Fatal("no change reported")
+			return nil
+		}
+	}
+
+	// Repeated writes are debounced into a single change.
+	writeFile(t, file, "foo1")
+	writeFile(t, file, "foo2")
+	assert.Equal(t, entries[:1], next())
+
+	// Files in new subdirectories are watched.
+	writeFile(t, filepath.Join(tree, "sub", "baz.txt"), "baz")
+	assert.Equal(t, entries[1:2], next())
+	writeFile(t, filepath.Join(tree, "sub", "baz.txt"), "baz2")
+	assert.Equal(t, entries[1:2], next())
+
+	// The glob entry watches the files matching its pattern.
+	writeFile(t, filepath.Join(dir, "single", "other.txt"), "changed")
+	writeFile(t, filepath.Join(dir, "single", "readme.md"), "readme")
+	assert.Equal(t, entries[2:3], next())
+
+	cancel()
+	assert.NoError(t, <-done)
+}
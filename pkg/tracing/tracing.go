@@ -0,0 +1,181 @@
+//
+// Copyright (c) 2024 Quadient Group AG
+//
+// This file is subject to the terms and conditions defined in the
+// 'LICENSE' file found in the root of this source code package.
+//
+
+// Package tracing sets up OpenTelemetry tracing of commands, event bus
+// events and Scaler requests.
+package tracing
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/robertwtucker/spt-util/pkg/constants"
+	"github.com/robertwtucker/spt-util/pkg/metrics"
+	"github.com/robertwtucker/spt-util/pkg/version"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Span exporters.
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+
+	// exporterConsole is the OTEL_TRACES_EXPORTER name of ExporterStdout.
+	exporterConsole = "console"
+)
+
+// instrumentation names the tracer of the application.
+const instrumentation = "github.com/robertwtucker/spt-util"
+
+// Shutdown flushes the spans recorded and stops the exporter.
+type Shutdown func(ctx context.Context) error
+
+// Setup installs the global tracer provider exporting spans with the
+// given exporter. OTLP spans are sent over HTTP to the endpoint URL, or
+// the one given by the standard OTEL_EXPORTER_OTLP_* environment
+// variables if empty; stdout spans are written to out as JSON. With
+// ExporterNone (or ""), spans are not recorded.
+func Setup(ctx context.Context, exporter string, endpoint string, out io.Writer) (Shutdown, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{},
+	))
+
+	var spanExporter sdktrace.SpanExporter
+	var err error
+	switch strings.ToLower(exporter) {
+	case ExporterNone, "":
+		return func(context.Context) error { return nil }, nil
+	case ExporterStdout, exporterConsole:
+		spanExporter, err = stdouttrace.New(stdouttrace.WithWriter(out))
+	case ExporterOTLP:
+		var options []otlptracehttp.Option
+		if options, err = endpointOptions(endpoint); err == nil {
+			spanExporter, err = otlptracehttp.New(ctx, options...)
+		}
+	default:
+		return nil, errors.Errorf("unsupported trace exporter: %s", exporter)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating trace exporter")
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(constants.AppName),
+		semconv.ServiceVersion(version.GetVersion()),
+	))
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating trace resource")
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(spanExporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// endpointOptions returns the OTLP exporter options for an endpoint URL
+// such as http://otel-collector:4318.
+func endpointOptions(endpoint string) ([]otlptracehttp.Option, error) {
+	if endpoint == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return nil, errors.Errorf("invalid OTLP endpoint: %s", endpoint)
+	}
+
+	options := []otlptracehttp.Option{otlptracehttp.WithEndpoint(u.Host)}
+	if u.Scheme == "http" {
+		options = append(options, otlptracehttp.WithInsecure())
+	}
+	if u.Path != "" && u.Path != "/" {
+		options = append(options, otlptracehttp.WithURLPath(u.Path))
+	}
+	return options, nil
+}
+
+// Tracer returns the tracer of the application.
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentation)
+}
+
+// Fail records an error on the span, if any, and marks it as failed.
+func Fail(span trace.Span, err error) {
+	if err == nil || span == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// Transport returns an http.RoundTripper tracing the requests made
+// through next (or the default transport, if nil) as client spans and
+// propagating the trace context in their headers.
+func Transport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return roundTripper{next: next}
+}
+
+// roundTripper traces HTTP requests.
+type roundTripper struct {
+	next http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper. Spans are named after the
+// method and the endpoint of the request, with resource IDs replaced.
+func (t roundTripper) RoundTrip(request *http.Request) (*http.Response, error) {
+	ctx, span := Tracer().Start(request.Context(), request.Method+" "+metrics.Endpoint(request.URL.Path),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(request.Method),
+			semconv.URLFull(redactURL(request.URL)),
+			semconv.ServerAddress(request.URL.Hostname()),
+		),
+	)
+	defer span.End()
+
+	// RoundTrippers must not modify the request.
+	request = request.Clone(ctx)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(request.Header))
+
+	response, err := t.next.RoundTrip(request)
+	if err != nil {
+		Fail(span, err)
+		return response, err
+	}
+	span.SetAttributes(semconv.HTTPResponseStatusCode(response.StatusCode))
+	if response.StatusCode >= http.StatusBadRequest {
+		span.SetStatus(codes.Error, response.Status)
+	}
+	return response, nil
+}
+
+// redactURL returns the URL without user info and query.
+func redactURL(u *url.URL) string {
+	redacted := *u
+	redacted.User = nil
+	redacted.RawQuery = ""
+	return redacted.String()
+}
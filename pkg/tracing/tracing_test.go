@@ -0,0 +1,61 @@
+//
+// Copyright (c) 2024 Quadient Group AG
+//
+// This file is subject to the terms and conditions defined in the
+// 'LICENSE' file found in the root of this source code package.
+//
+
+package tracing_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/robertwtucker/spt-util/pkg/tracing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetup_Unsupported(t *testing.T) {
+	_, err := tracing.Setup(context.Background(), "zipkin", "", nil)
+	assert.Error(t, err)
+}
+
+func TestSetup_InvalidEndpoint(t *testing.T) {
+	_, err := tracing.Setup(context.Background(), tracing.ExporterOTLP, "collector:4318", nil)
+	assert.Error(t, err)
+}
+
+func TestTransport(t *testing.T) {
+	var traceparent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	out := &bytes.Buffer{}
+	shutdown, err := tracing.Setup(context.Background(), tracing.ExporterStdout, "", out)
+	require.NoError(t, err)
+
+	ctx, span := tracing.Tracer().Start(context.Background(), "command")
+	request, err := http.NewRequestWithContext(ctx, http.MethodPatch, server.URL+"/api/integration/v2/workflows/42", nil)
+	require.NoError(t, err)
+	request.SetBasicAuth("user", "secret")
+	client := &http.Client{Transport: tracing.Transport(nil)}
+	response, err := client.Do(request)
+	require.NoError(t, err)
+	_ = response.Body.Close()
+	span.End()
+	require.NoError(t, shutdown(context.Background()))
+
+	// The request carries the trace of the calling span.
+	assert.Contains(t, traceparent, span.SpanContext().TraceID().String())
+	assert.Empty(t, request.Header.Get("traceparent"), "request modified")
+	assert.Contains(t, out.String(), `"Name":"PATCH /api/integration/v2/workflows/{id}"`)
+	assert.Contains(t, out.String(), `"Code":"Error"`)
+	assert.NotContains(t, out.String(), "secret")
+}